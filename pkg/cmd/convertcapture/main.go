@@ -0,0 +1,59 @@
+// Command convertcapture converts telemetry captures between the radio CSV
+// format and the columnar format:
+//
+//	convertcapture -format columnar flight.log flight.col
+//	convertcapture -format csv flight.col flight.log
+//
+// The input format is detected from the file. CSV captures are read and
+// written with the default parser settings, or those of the datasource's
+// "parser" settings saved as JSON to the file given with -parser.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/dibes/rocket-telemtry/pkg/plugin"
+)
+
+func main() {
+	format := flag.String("format", models.FormatColumnar, "output format, csv or columnar")
+	parserFile := flag.String("parser", "", "JSON file of parser settings, e.g. {\"timeUnit\": \"s\"}")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: convertcapture [-format csv|columnar] [-parser file] in out")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	parser, err := loadParser(*parserFile)
+	if err == nil {
+		err = plugin.ConvertCapture(flag.Arg(0), flag.Arg(1), *format, parser)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "convertcapture:", err)
+		os.Exit(1)
+	}
+}
+
+// loadParser reads parser settings from path on top of the defaults.
+func loadParser(path string) (models.ParserConfig, error) {
+	parser := models.DefaultPluginSettings().Parser
+	if path == "" {
+		return parser, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return parser, err
+	}
+	if err := json.Unmarshal(raw, &parser); err != nil {
+		return parser, fmt.Errorf("parser settings %s: %w", path, err)
+	}
+	return parser, nil
+}
@@ -0,0 +1,73 @@
+package models
+
+// SimulationPreset is a named, coherent set of simulation parameters.
+type SimulationPreset struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Simulation  SimulationConfig `json:"simulation"`
+}
+
+// SimulationPresets lists the built in presets, selected through
+// SimulationConfig.Preset.
+var SimulationPresets = []SimulationPreset{
+	{
+		Name:        "estes-alpha",
+		Description: "Small model rocket on an A or B motor, about 300 m apogee under a streamer.",
+		Simulation: preset(func(c *SimulationConfig) {
+			c.LaunchVelocity = 77
+			c.DescentRate = 4
+			c.SpinRate = 30
+		}),
+	},
+	{
+		Name:        "high-power-l1",
+		Description: "Level 1 certification flight on an H motor, about 1.1 km apogee with a slight weathercock.",
+		Simulation: preset(func(c *SimulationConfig) {
+			c.LaunchVelocity = 150
+			c.DescentRate = 6
+			c.LaunchAngle = 5
+			c.LaunchAzimuth = 270
+		}),
+	},
+	{
+		Name:        "two-stage",
+		Description: "Booster and sustainer, the sustainer lighting 3 s after liftoff for about 2.5 km apogee.",
+		Simulation: preset(func(c *SimulationConfig) {
+			c.LaunchVelocity = 110
+			c.SecondStageVelocity = 110
+			c.SecondStageDelay = 3
+			c.DescentRate = 8
+		}),
+	},
+	{
+		Name:        "bad-day",
+		Description: "Scrubbed countdowns, hang-fires and GPS dropouts, for rehearsing anomalies.",
+		Simulation: preset(func(c *SimulationConfig) {
+			c.AbortProbability = 0.3
+			c.RetryDelay = 20
+			c.HangFireProbability = 0.3
+			c.GPSDropoutRate = 0.05
+			c.GPSDropoutDuration = 4
+			c.DeadReckoning = true
+		}),
+	},
+}
+
+// preset builds a preset config from the defaults.
+func preset(set func(*SimulationConfig)) SimulationConfig {
+	cfg := DefaultPluginSettings().Simulation
+	set(&cfg)
+	return cfg
+}
+
+// SimulationPresetConfig returns the config of the named preset.
+func SimulationPresetConfig(name string) (SimulationConfig, bool) {
+	for _, p := range SimulationPresets {
+		if p.Name == name {
+			cfg := p.Simulation
+			cfg.Preset = name
+			return cfg, true
+		}
+	}
+	return SimulationConfig{}, false
+}
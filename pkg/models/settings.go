@@ -8,19 +8,502 @@ import (
 )
 
 type PluginSettings struct {
-	Path    string                `json:"path"`
-	Secrets *SecretPluginSettings `json:"-"`
+	Path    string       `json:"path"`
+	Source  string       `json:"source"` // "simulation" (default), "file" or "serial"
+	File    string       `json:"file"`
+	Follow  bool         `json:"follow"`
+	Reverse bool         `json:"reverse"` // see SourceConfig
+	Serial  SerialConfig `json:"serial"`
+	// Sources lists several sources in priority order for failover. When
+	// empty the single Source above is used.
+	Sources []SourceConfig `json:"sources"`
+	// FlightDir is the flight store: a directory holding one capture file per
+	// recorded flight, named after the flight.
+	FlightDir string `json:"flightDir"`
+	// MaxPacketRate caps ingestion in packets per second, coalescing excess
+	// packets into the most recent one. 0 disables the cap.
+	MaxPacketRate float64 `json:"maxPacketRate"`
+	// ZeroAltitude rebases altitude to 0 at stream start and at every launch.
+	ZeroAltitude bool `json:"zeroAltitude"`
+	// ClampAltitude floors emitted altitude at 0, or at the launch reference
+	// with ZeroAltitude. The unclamped value stays available as rawAltitude.
+	ClampAltitude bool `json:"clampAltitude"`
+	// RailLength is the height in m above the pad at which the rocket clears
+	// the launch rail, where railExitVelocity is taken. 0 disables it.
+	RailLength float64 `json:"railLength"`
+	// Recording persists every streamed packet to disk.
+	Recording RecordingConfig `json:"recording"`
+	// ErrorLogSize is how many recent parse errors /errors keeps.
+	ErrorLogSize int `json:"errorLogSize"`
+	// Geofence hides the rocket's position while it is outside the fence.
+	Geofence GeofenceConfig `json:"geofence"`
+	// Saturation flags an altimeter pinned at the top of its range.
+	Saturation SaturationConfig `json:"saturation"`
+	// LandingZone sizes the search area around the predicted landing spot.
+	LandingZone LandingZoneConfig `json:"landingZone"`
+	// Wind is added to the current ground track when predicting the landing
+	// spot, for wind the rocket has not drifted in yet, such as a stronger
+	// surface wind. Leave it at 0 to extrapolate the ground track alone.
+	Wind       WindConfig            `json:"wind"`
+	Safety     SafetyConfig          `json:"safety"`
+	Signal     SignalConfig          `json:"signal"`
+	Simulation SimulationConfig      `json:"simulation"`
+	Parser     ParserConfig          `json:"parser"`
+	RateLimit  RateLimitConfig       `json:"rateLimit"`
+	Secrets    *SecretPluginSettings `json:"-"`
+}
+
+const (
+	SourceSimulation = "simulation"
+	SourceFile       = "file"
+	SourceSerial     = "serial"
+)
+
+// SourceConfig configures one telemetry source.
+type SourceConfig struct {
+	Name   string       `json:"name"`
+	Type   string       `json:"type"` // SourceSimulation, SourceFile or SourceSerial
+	File   string       `json:"file"`
+	Follow bool         `json:"follow"`
+	Serial SerialConfig `json:"serial"`
+	// Reverse replays the file backwards, with timestamps mirrored so time
+	// still advances. It cannot be combined with Follow.
+	Reverse bool `json:"reverse"`
+	// StaleAfter is how many seconds the source may go without a packet
+	// before the stream fails over to the next source.
+	StaleAfter float64 `json:"staleAfter"`
+}
+
+// SourceConfigs returns the configured sources in priority order.
+func (s PluginSettings) SourceConfigs() []SourceConfig {
+	if len(s.Sources) > 0 {
+		return s.Sources
+	}
+	return []SourceConfig{{Name: s.Source, Type: s.Source, File: s.File, Follow: s.Follow, Reverse: s.Reverse, Serial: s.Serial}}
+}
+
+// SerialConfig configures a serial radio receiver.
+type SerialConfig struct {
+	Device string `json:"device"` // e.g. /dev/ttyUSB0
+	Baud   int    `json:"baud"`
+	// ReadTimeout bounds each read in seconds. A read that times out counts as
+	// a missed packet; MaxMissed misses in a row reopen the port.
+	ReadTimeout float64 `json:"readTimeout"`
+	MaxMissed   int     `json:"maxMissed"`
+	// DataBits (5-8), Parity (ParityNone, ParityEven, ParityOdd), StopBits
+	// (1 or 2) and FlowControl (FlowNone, FlowRTSCTS, FlowXonXoff) frame the
+	// bytes on the line, 8N1 without flow control by default.
+	DataBits    int    `json:"dataBits"`
+	Parity      string `json:"parity"`
+	StopBits    int    `json:"stopBits"`
+	FlowControl string `json:"flowControl"`
+}
+
+const (
+	ParityNone = "none"
+	ParityEven = "even"
+	ParityOdd  = "odd"
+
+	FlowNone    = "none"
+	FlowRTSCTS  = "rtscts"
+	FlowXonXoff = "xonxoff"
+)
+
+// WindConfig is a wind speed in m/s blowing from Direction, in degrees
+// clockwise from north.
+type WindConfig struct {
+	Speed     float64 `json:"speed"`
+	Direction float64 `json:"direction"`
+}
+
+// RecordingConfig appends streamed packets to File as Format: FormatCSV,
+// radio lines any file source reads, or the more compact and faster to load
+// FormatColumnar, see plugin.ReadColumnar. Recording is off without a File.
+type RecordingConfig struct {
+	File   string `json:"file"`
+	Format string `json:"format"`
+}
+
+const (
+	FormatCSV      = "csv"
+	FormatColumnar = "columnar"
+)
+
+// GeofenceConfig is a circle of Radius meters around a center. The fence is
+// disabled while Radius is 0.
+type GeofenceConfig struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Radius    float64 `json:"radius"`
+}
+
+// LandingZoneConfig sizes the landing zone polygon: a circle of Radius m
+// around the predicted landing spot, growing by WindUncertainty m/s of
+// unpredicted drift for every second of descent left, drawn with Vertices
+// corners.
+type LandingZoneConfig struct {
+	Radius          float64 `json:"radius"`
+	WindUncertainty float64 `json:"windUncertainty"`
+	Vertices        int     `json:"vertices"`
+}
+
+// SaturationConfig describes the range of the altimeter. Altitude is the
+// highest reading in m it can report, 0 when it does not saturate. Packets is
+// how many consecutive readings within Tolerance m of it, while the rocket is
+// still climbing, flag the sensor as saturated.
+type SaturationConfig struct {
+	Altitude  float64 `json:"altitude"`
+	Packets   int     `json:"packets"`
+	Tolerance float64 `json:"tolerance"`
+}
+
+// SafetyConfig holds the thresholds of the range-safety envelope. Any value
+// left out of the datasource JSON keeps its default.
+type SafetyConfig struct {
+	MaxGForce      float64 `json:"maxGForce"`
+	MaxDescentRate float64 `json:"maxDescentRate"` // m/s, positive downwards
+	Ceiling        float64 `json:"ceiling"`        // m
+	MinSignal      int     `json:"minSignal"`      // dBm
+	// MaxVelocityDivergence is how far, in m/s, the velocity implied by the
+	// altitude may stray from the reported velocity before the data is
+	// flagged as inconsistent.
+	MaxVelocityDivergence float64 `json:"maxVelocityDivergence"`
+}
+
+// SignalConfig is the dBm range mapped onto 0-100% for signalPercent.
+// ExpectedInterval is the seconds between packets the link should deliver,
+// against which gaps are counted as lost packets for packetLossPercent. A
+// phase with a Simulation.Cadence set is measured against that instead.
+type SignalConfig struct {
+	MinDBm           float64 `json:"minDbm"`
+	MaxDBm           float64 `json:"maxDbm"`
+	ExpectedInterval float64 `json:"expectedInterval"`
+}
+
+// SimulationConfig tunes the simulated flight.
+type SimulationConfig struct {
+	// Seed fixes the random sequence of the simulation so a flight can be
+	// replayed. 0 derives a seed from the start time; the effective seed is
+	// reported in frame metadata and by /sim/config.
+	Seed int64 `json:"seed"`
+	// Preset starts from one of SimulationPresets; fields set alongside it
+	// override the preset's values.
+	Preset      string  `json:"preset"`
+	LaunchDelay float64 `json:"launchDelay"` // s on the pad before ignition
+	// LaunchVelocity is the speed off the rail in m/s and DescentRate the
+	// parachute's terminal descent rate in m/s.
+	LaunchVelocity float64 `json:"launchVelocity"`
+	DescentRate    float64 `json:"descentRate"`
+	// SecondStageVelocity adds a sustainer burn of that many m/s,
+	// SecondStageDelay seconds after liftoff.
+	SecondStageVelocity float64 `json:"secondStageVelocity"`
+	SecondStageDelay    float64 `json:"secondStageDelay"`
+	// AbortProbability is the chance that a countdown scrubs AbortAt seconds
+	// before ignition. A scrubbed countdown recycles after RetryDelay seconds,
+	// or holds indefinitely when RetryDelay is 0.
+	AbortProbability float64 `json:"abortProbability"`
+	AbortAt          float64 `json:"abortAt"`
+	RetryDelay       float64 `json:"retryDelay"`
+	// SpinRate rolls the rocket continuously in flight, in degrees per second.
+	SpinRate float64 `json:"spinRate"`
+	// LaunchAngle tilts the launch rail from vertical, towards LaunchAzimuth
+	// (degrees clockwise from north).
+	LaunchAngle   float64 `json:"launchAngle"`
+	LaunchAzimuth float64 `json:"launchAzimuth"`
+	// TrajectoryFile replays a CSV of waypoints instead of the built in
+	// physics, see plugin.ParseTrajectory for the format.
+	TrajectoryFile string `json:"trajectoryFile"`
+	// RequireArming keeps the rocket on the pad until it is armed through the
+	// /sim endpoint, after which the LaunchDelay countdown runs.
+	RequireArming bool `json:"requireArming"`
+	// GPSDropoutRate is the chance per second that the GPS loses its fix for
+	// GPSDropoutDuration seconds. With DeadReckoning the receiver keeps
+	// advancing the position from its last velocity instead of reporting 0,0.
+	GPSDropoutRate     float64 `json:"gpsDropoutRate"`
+	GPSDropoutDuration float64 `json:"gpsDropoutDuration"`
+	DeadReckoning      bool    `json:"deadReckoning"`
+	// GPSBias offsets every reported position by GPSBias meters towards
+	// GPSBiasBearing (degrees clockwise from north), as a systematic error of
+	// an uncalibrated receiver.
+	GPSBias        float64 `json:"gpsBias"`
+	GPSBiasBearing float64 `json:"gpsBiasBearing"`
+	// HangFireProbability is the chance that the motor does not light when
+	// the igniter fires. The rocket sits in IGNITION for HangFireDelay seconds
+	// before thrust, or returns to LANDED after HangFireTimeout seconds when
+	// the delay is not shorter than the timeout, i.e. the motor never lights.
+	HangFireProbability float64 `json:"hangFireProbability"`
+	HangFireDelay       float64 `json:"hangFireDelay"`
+	HangFireTimeout     float64 `json:"hangFireTimeout"`
+	// DescentSpeedup multiplies the parachute descent rate, and
+	// MaxDescentTime lands the rocket that many seconds after apogee (0 for
+	// no limit), to shorten demo loops. The ascent is unaffected.
+	DescentSpeedup float64 `json:"descentSpeedup"`
+	MaxDescentTime float64 `json:"maxDescentTime"`
+	// Cadence sets the stream interval per flight phase.
+	Cadence CadenceConfig `json:"cadence"`
+	// SensorFailure kills a single sensor partway through each flight.
+	SensorFailure SensorFailureConfig `json:"sensorFailure"`
+	// IdleNoise jitters the telemetry while the rocket waits on the pad, so
+	// the dashboard looks alive rather than frozen.
+	IdleNoise IdleNoiseConfig `json:"idleNoise"`
+	// Radio limits the simulated telemetry link by range.
+	Radio RadioConfig `json:"radio"`
+	// Detection runs the launch detector on the simulated motion, emitting
+	// its inferred state as detectedState next to the true state.
+	Detection DetectionConfig `json:"detection"`
+	// PadNorth and PadEast move the launch pad that many meters from the
+	// default launch site.
+	PadNorth float64 `json:"padNorth"`
+	PadEast  float64 `json:"padEast"`
+	// Formation flies several simulated rockets side by side, one per query.
+	Formation FormationConfig `json:"formation"`
+	// GForceMethod picks how the simulated gforce is derived from the motion:
+	// GForceAcceleration, GForceIMU or GForceApprox.
+	GForceMethod string `json:"gforceMethod"`
+}
+
+const (
+	// GForceAcceleration is the net vertical acceleration plus gravity, in g,
+	// as a single axis accelerometer along the rocket reads it: 1 at rest or
+	// under canopy, 0 in a drag-free coast. It is signed.
+	GForceAcceleration = "acceleration"
+	// GForceIMU is the magnitude of the specific force vector, vertical and
+	// horizontal, as a 3-axis IMU reports it. It is never negative.
+	GForceIMU = "imu"
+	// GForceApprox is the original approximation of 1 + v/98 for a vertical
+	// speed v in m/s, which follows the speed rather than the acceleration.
+	GForceApprox = "approx"
+)
+
+// FormationConfig spreads Count simulated rockets apart so their tracks stay
+// distinct. The pads are Spacing meters apart on a line across the launch
+// azimuth, each rocket launches Stagger seconds after the one before it, and
+// its launch angle and azimuth are perturbed by up to Perturbation degrees.
+// A query picks its rocket with the rocket option.
+type FormationConfig struct {
+	Count        int     `json:"count"`
+	Spacing      float64 `json:"spacing"`
+	Stagger      float64 `json:"stagger"`
+	Perturbation float64 `json:"perturbation"`
+}
+
+// CadenceConfig holds the seconds between simulated packets on the pad
+// (LANDED, CALIBRATION), in ascent (IGNITION, LAUNCHING, APEX) and in
+// descent. A phase left at 0 uses the default 500ms, so the cadence is
+// constant unless configured, e.g. {"pad": 2, "ascent": 0.1, "descent": 0.5}.
+type CadenceConfig struct {
+	Pad     float64 `json:"pad"`
+	Ascent  float64 `json:"ascent"`
+	Descent float64 `json:"descent"`
+}
+
+const (
+	SensorZero  = "zero"
+	SensorStuck = "stuck"
+)
+
+// RadioConfig models the simulated radio link with a log-distance path loss:
+// the RSSI is ReferenceSignal dBm at 100 m of slant range from the ground
+// station and falls by 10*PathLossExponent dB per decade of distance. Packets
+// beyond MaxRange meters, or weaker than Sensitivity dBm, are lost. The
+// ground station is at Latitude, Longitude, or at the launch site when those
+// are 0. The model is off while MaxRange is 0.
+type RadioConfig struct {
+	MaxRange         float64 `json:"maxRange"`
+	ReferenceSignal  float64 `json:"referenceSignal"`
+	PathLossExponent float64 `json:"pathLossExponent"`
+	Sensitivity      float64 `json:"sensitivity"`
+	Latitude         float64 `json:"latitude"`
+	Longitude        float64 `json:"longitude"`
+}
+
+// DetectionConfig holds the thresholds of the state detector, which infers
+// the flight phase from altitude alone: launch once climbing faster than
+// LaunchVelocity m/s, apex once the climb stops, descent once sinking faster
+// than DescentVelocity m/s, and landed once below LandedAltitude meters and
+// slower than LandedVelocity m/s.
+type DetectionConfig struct {
+	Enabled         bool    `json:"enabled"`
+	LaunchVelocity  float64 `json:"launchVelocity"`
+	DescentVelocity float64 `json:"descentVelocity"`
+	LandedAltitude  float64 `json:"landedAltitude"`
+	LandedVelocity  float64 `json:"landedVelocity"`
+}
+
+// IdleNoiseConfig holds the standard deviation of the noise added to each
+// LANDED packet: Altitude in meters, Attitude in degrees on pitch, roll and
+// yaw, GForce in g and Signal in dBm. Only the reported values are noisy, the
+// simulated rocket stays put. Off unless Enabled.
+//
+// There is no battery drift: the telemetry carries no battery voltage, in the
+// radio format or the frames, so there is no reading to drift. It belongs
+// here once the avionics report one.
+type IdleNoiseConfig struct {
+	Enabled  bool    `json:"enabled"`
+	Altitude float64 `json:"altitude"`
+	Attitude float64 `json:"attitude"`
+	GForce   float64 `json:"gforce"`
+	Signal   float64 `json:"signal"`
+}
+
+// SensorFailureConfig makes Field (altitude, pitch, roll, yaw, gforce,
+// latitude or longitude) read 0, or stay stuck at its last value, from After
+// seconds after launch until the rocket lands.
+type SensorFailureConfig struct {
+	Field string  `json:"field"`
+	Mode  string  `json:"mode"` // SensorZero (default) or SensorStuck
+	After float64 `json:"after"`
+}
+
+// ParserConfig maps vendor specific state strings onto the standard state
+// names (LANDED, LAUNCHING, APEX, DESCENDING, CALIBRATION, IGNITION,
+// UNKNOWN). Configured aliases are added to the defaults. States matching
+// neither are handled according to UnknownState: parsed as the state it
+// names (UNKNOWN by default, LANDED for the old behavior) and logged, kept at
+// the last known state with UnknownStateLast, or rejected as a parse error
+// with UnknownStateError.
+type ParserConfig struct {
+	StateAliases map[string]string `json:"stateAliases"`
+	UnknownState string            `json:"unknownState"`
+	// RecordDelimiter separates the records of a radio line carrying several
+	// packets, "|" by default. Empty parses every line as a single packet.
+	RecordDelimiter string `json:"recordDelimiter"`
+	// MaxGForce clamps parsed g-force readings beyond ±MaxGForce, which are
+	// parsing glitches rather than flight data. 0 disables the clamp.
+	MaxGForce float64 `json:"maxGForce"`
+	// TimeUnit is the unit of parsed timestamps: TimeUnitMilliseconds
+	// (default), TimeUnitSeconds, TimeUnitMicroseconds or TimeUnitAuto, which
+	// guesses from the magnitude of epoch timestamps and takes anything too
+	// small to be one as milliseconds.
+	TimeUnit string `json:"timeUnit"`
+}
+
+const (
+	TimeUnitMilliseconds = "ms"
+	TimeUnitSeconds      = "s"
+	TimeUnitMicroseconds = "us"
+	TimeUnitAuto         = "auto"
+)
+
+const (
+	UnknownStateLast  = "last"
+	UnknownStateError = "error"
+)
+
+// RateLimitConfig limits requests to the resource endpoints. Endpoints are
+// keyed by path, e.g. "/latest", and fall back to Default.
+type RateLimitConfig struct {
+	Default   RateLimit            `json:"default"`
+	Endpoints map[string]RateLimit `json:"endpoints"`
+}
+
+// RateLimit is a token bucket refilled at Rate requests per second holding up
+// to Burst requests. A zero Rate disables the limit.
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
 }
 
 type SecretPluginSettings struct {
 	ApiKey string `json:"apiKey"`
 }
 
+func DefaultPluginSettings() PluginSettings {
+	return PluginSettings{
+		Source:        SourceSimulation,
+		ErrorLogSize:  100,
+		RailLength:    2,
+		Recording:     RecordingConfig{Format: FormatCSV},
+		MaxPacketRate: 50,
+		Serial:        DefaultSerialConfig(),
+		LandingZone: LandingZoneConfig{
+			Radius:          50,
+			WindUncertainty: 2,
+			Vertices:        16,
+		},
+		Saturation: SaturationConfig{
+			Packets:   3,
+			Tolerance: 0.5,
+		},
+		Safety: SafetyConfig{
+			MaxGForce:             15,
+			MaxDescentRate:        30,
+			Ceiling:               3000,
+			MinSignal:             -110,
+			MaxVelocityDivergence: 15,
+		},
+		Signal: SignalConfig{
+			MinDBm:           -120,
+			MaxDBm:           -30,
+			ExpectedInterval: 0.5,
+		},
+		Simulation: SimulationConfig{
+			LaunchDelay:     5,
+			LaunchVelocity:  150,
+			DescentRate:     10,
+			AbortAt:         1,
+			HangFireDelay:   3,
+			HangFireTimeout: 30,
+			DescentSpeedup:  1,
+			GForceMethod:    GForceAcceleration,
+			SensorFailure:   SensorFailureConfig{Mode: SensorZero},
+			IdleNoise:       IdleNoiseConfig{Altitude: 0.3, Attitude: 0.2, GForce: 0.01, Signal: 2},
+			Radio:           RadioConfig{ReferenceSignal: -50, PathLossExponent: 2, Sensitivity: -120},
+			Detection:       DetectionConfig{LaunchVelocity: 10, DescentVelocity: 2, LandedAltitude: 5, LandedVelocity: 1},
+		},
+		Parser: ParserConfig{
+			StateAliases: map[string]string{
+				"BOOST":  "LAUNCHING",
+				"COAST":  "LAUNCHING",
+				"DROGUE": "DESCENDING",
+				"MAIN":   "DESCENDING",
+				"LAND":   "LANDED",
+			},
+			UnknownState:    "UNKNOWN",
+			RecordDelimiter: "|",
+			MaxGForce:       100,
+			TimeUnit:        TimeUnitMilliseconds,
+		},
+		RateLimit: RateLimitConfig{
+			Default: RateLimit{Rate: 20, Burst: 40},
+		},
+	}
+}
+
+// DefaultSerialConfig is used for the single serial source and fills in
+// values left out of serial entries in Sources.
+func DefaultSerialConfig() SerialConfig {
+	return SerialConfig{
+		Baud:        9600,
+		ReadTimeout: 5,
+		MaxMissed:   3,
+		DataBits:    8,
+		Parity:      ParityNone,
+		StopBits:    1,
+		FlowControl: FlowNone,
+	}
+}
+
 func LoadPluginSettings(source backend.DataSourceInstanceSettings) (*PluginSettings, error) {
-	settings := PluginSettings{}
-	err := json.Unmarshal(source.JSONData, &settings)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal PluginSettings json: %w", err)
+	settings := DefaultPluginSettings()
+	if len(source.JSONData) > 0 {
+		err := json.Unmarshal(source.JSONData, &settings)
+		if err != nil {
+			return nil, fmt.Errorf("could not unmarshal PluginSettings json: %w", err)
+		}
+	}
+
+	// Decode again on top of the preset so explicit fields win over it.
+	if name := settings.Simulation.Preset; name != "" {
+		preset, ok := SimulationPresetConfig(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown simulation preset %q", name)
+		}
+		settings = DefaultPluginSettings()
+		settings.Simulation = preset
+		if err := json.Unmarshal(source.JSONData, &settings); err != nil {
+			return nil, fmt.Errorf("could not unmarshal PluginSettings json: %w", err)
+		}
 	}
 
 	settings.Secrets = loadSecretPluginSettings(source.DecryptedSecureJSONData)
@@ -0,0 +1,22 @@
+package plugin
+
+// launchZero rebases altitude so that every flight starts at 0. The reference
+// is captured from the first packet seen and again on each transition from
+// the pad to LAUNCHING.
+type launchZero struct {
+	ref       float64
+	set       bool
+	prevState RocketState
+}
+
+// Apply returns the packet with its altitude relative to the current reference.
+func (z *launchZero) Apply(packet TelemetryPacket) TelemetryPacket {
+	if !z.set || (onPad(z.prevState) && packet.State == LAUNCHING) {
+		z.ref = packet.Altitude
+		z.set = true
+	}
+	z.prevState = packet.State
+
+	packet.Altitude -= z.ref
+	return packet
+}
@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestLaunchZeroAcrossFlights(t *testing.T) {
+	packets := []TelemetryPacket{
+		// First flight from a pad at 100m.
+		{State: LANDED, Altitude: 100},
+		{State: LANDED, Altitude: 101},
+		{State: LAUNCHING, Altitude: 102},
+		{State: LAUNCHING, Altitude: 400},
+		{State: APEX, Altitude: 600},
+		{State: DESCENDING, Altitude: 300},
+		{State: LANDED, Altitude: 103},
+		// Second flight from a pad at 120m.
+		{State: LANDED, Altitude: 120},
+		{State: LAUNCHING, Altitude: 121},
+		{State: APEX, Altitude: 621},
+		{State: LANDED, Altitude: 250},
+		// Third flight through a hang fire.
+		{State: IGNITION, Altitude: 250},
+		{State: LAUNCHING, Altitude: 260},
+		{State: APEX, Altitude: 460},
+	}
+	want := []float64{0, 1, 0, 298, 498, 198, 1, 18, 0, 500, 129, 129, 0, 200}
+
+	z := &launchZero{}
+	for i, p := range packets {
+		if got := z.Apply(p).Altitude; got != want[i] {
+			t.Errorf("packet %d: got altitude %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestClampAltitude(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	settings.ClampAltitude = true
+
+	q := defaultQuery()
+	q.Fields = []string{"altitude", "rawAltitude"}
+
+	frame := newFrameBuilder(q, settings).Build(TelemetryPacket{Altitude: -1.5})
+	altitude, _ := frame.FieldByName("altitude")
+	raw, _ := frame.FieldByName("rawAltitude")
+
+	if got := altitude.At(0).(float64); got != 0 {
+		t.Errorf("got altitude %v, want 0", got)
+	}
+	if got := raw.At(0).(float64); got != -1.5 {
+		t.Errorf("got raw altitude %v, want -1.5", got)
+	}
+}
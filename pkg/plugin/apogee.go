@@ -0,0 +1,48 @@
+package plugin
+
+import "math"
+
+// apogeeEstimator smooths PredictApogee into apogeeEstimate. While climbing
+// the estimate rises from the launch altitude and only moves up, by alpha of
+// the way towards a higher prediction, never sitting below the current
+// altitude, so it converges on the apogee without following the noise in the
+// climb rate. Once the rocket stops climbing it is the highest altitude
+// reached, and stays there after landing until the next launch. It is 0
+// before the first launch.
+type apogeeEstimator struct {
+	alpha    float64
+	estimate float64
+	peak     float64
+	started  bool
+	landed   bool
+}
+
+func (a *apogeeEstimator) Update(state RocketState, altitude, verticalSpeed float64) float64 {
+	switch state {
+	case IGNITION, LAUNCHING:
+		if a.landed {
+			*a = apogeeEstimator{alpha: a.alpha}
+		}
+	case DESCENDING:
+		a.landed = true
+	}
+	if a.started {
+		a.peak = math.Max(a.peak, altitude)
+	}
+	if state != LAUNCHING {
+		if !a.started {
+			return 0
+		}
+		return a.peak
+	}
+
+	predicted := math.Max(PredictApogee(altitude, verticalSpeed), altitude)
+	if !a.started {
+		a.estimate, a.peak, a.started = altitude, altitude, true
+	}
+	if predicted > a.estimate {
+		a.estimate += a.alpha * (predicted - a.estimate)
+	}
+	a.estimate = math.Max(a.estimate, altitude)
+	return a.estimate
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApogeeEstimator(t *testing.T) {
+	a := apogeeEstimator{alpha: 0.2}
+	if got := a.Update(LANDED, 3, 0); got != 0 {
+		t.Errorf("on the pad: got %v, want 0", got)
+	}
+
+	// A drag-free coast from 100 m/s tops out at 509.9 m. The reported climb
+	// rate is off by up to 8 m/s either way.
+	const v0, dt = 100.0, 0.5
+	apogee := v0 * v0 / (2 * standardGravity)
+	var last, rawJitter, jitter float64
+	prevRaw := math.NaN()
+	for i := 0; ; i++ {
+		tm := float64(i) * dt
+		v := v0 - standardGravity*tm
+		if v <= 0 {
+			break
+		}
+		alt := v0*tm - standardGravity*tm*tm/2
+		noisy := v + 8*math.Sin(float64(i)*2.1)
+
+		got := a.Update(LAUNCHING, alt, noisy)
+		if got < last {
+			t.Fatalf("t=%v: estimate fell from %v to %v while climbing", tm, last, got)
+		}
+		if got < alt {
+			t.Fatalf("t=%v: estimate %v below the altitude %v", tm, got, alt)
+		}
+		raw := PredictApogee(alt, noisy)
+		if !math.IsNaN(prevRaw) && i > 0 {
+			rawJitter += math.Abs(raw - prevRaw)
+			jitter += math.Abs(got - last)
+		}
+		prevRaw, last = raw, got
+	}
+	if math.Abs(last-apogee) > 0.1*apogee {
+		t.Errorf("converged on %v, want within 10%% of %v", last, apogee)
+	}
+	if jitter >= rawJitter/2 {
+		t.Errorf("estimate moved %v in total, the raw prediction %v", jitter, rawJitter)
+	}
+
+	if got := a.Update(APEX, 509.9, 0); got != 509.9 {
+		t.Errorf("at apex: got %v, want the peak 509.9", got)
+	}
+	a.Update(DESCENDING, 200, -20)
+	if got := a.Update(LANDED, 0, 0); got != 509.9 {
+		t.Errorf("landed: got %v, want the peak held at 509.9", got)
+	}
+	if got := a.Update(LAUNCHING, 10, 0); got != 10 {
+		t.Errorf("next launch: got %v, want a fresh estimate of 10", got)
+	}
+
+	unsmoothed := apogeeEstimator{alpha: 1}
+	unsmoothed.Update(LAUNCHING, 0, 50)
+	if got, want := unsmoothed.Update(LAUNCHING, 100, 20), PredictApogee(0, 50); got != want {
+		t.Errorf("alpha 1: got %v, want the highest prediction %v", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package plugin
+
+import "math"
+
+const (
+	gammaAir = 1.4     // ratio of specific heats
+	rAir     = 287.053 // specific gas constant, J/(kg·K)
+)
+
+// airTemperature returns the ISA standard temperature in kelvin at altitudeM,
+// covering the troposphere and the lower stratosphere.
+func airTemperature(altitudeM float64) float64 {
+	switch {
+	case altitudeM < 0:
+		altitudeM = 0
+		fallthrough
+	case altitudeM <= 11000:
+		return 288.15 - 0.0065*altitudeM
+	case altitudeM <= 20000:
+		return 216.65
+	default:
+		return 216.65 + 0.001*(math.Min(altitudeM, 32000)-20000)
+	}
+}
+
+// SpeedOfSound returns the speed of sound in m/s at altitudeM in the ISA model.
+func SpeedOfSound(altitudeM float64) float64 {
+	return math.Sqrt(gammaAir * rAir * airTemperature(altitudeM))
+}
+
+// MachNumber converts a speed in m/s at altitudeM to a Mach number.
+func MachNumber(speed, altitudeM float64) float64 {
+	return math.Abs(speed) / SpeedOfSound(altitudeM)
+}
@@ -0,0 +1,31 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpeedOfSound(t *testing.T) {
+	tests := []struct {
+		altitude float64
+		want     float64
+	}{
+		{0, 340.3},
+		{-50, 340.3},
+		{5000, 320.5},
+		{11000, 295.1},
+		{15000, 295.1},
+		{25000, 298.4},
+	}
+	for _, tt := range tests {
+		if got := SpeedOfSound(tt.altitude); math.Abs(got-tt.want) > 0.1 {
+			t.Errorf("SpeedOfSound(%v) = %.1f, want %.1f", tt.altitude, got, tt.want)
+		}
+	}
+}
+
+func TestMachNumber(t *testing.T) {
+	if got := MachNumber(-SpeedOfSound(0), 0); math.Abs(got-1) > 1e-9 {
+		t.Errorf("got Mach %v, want 1", got)
+	}
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"math"
+	"strconv"
+)
+
+// FormatAttitude renders the three angles compactly, e.g. "P:90.0 R:0.0 Y:0.0".
+func FormatAttitude(pitch, roll, yaw float64, precision int) string {
+	if precision < 0 {
+		precision = 0
+	}
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', precision, 64)
+	}
+	return "P:" + format(pitch) + " R:" + format(roll) + " Y:" + format(yaw)
+}
+
+// AngularDelta returns the shortest signed difference curr - prev in degrees,
+// wrapped to [-180, 180] so rates stay smooth across the 0/360 boundary.
+func AngularDelta(prev, curr float64) float64 {
+	d := math.Mod(curr-prev, 360)
+	switch {
+	case d > 180:
+		d -= 360
+	case d < -180:
+		d += 360
+	}
+	return d
+}
+
+// TiltFromVertical returns the angle in degrees between the rocket's axis and
+// the vertical, 0 standing on the rail and 90 flying level. pitch is the
+// elevation of the nose above the horizon, so a rocket pitched past 90 is
+// tilting over the other way. Roll turns the rocket about its own axis and
+// leaves the tilt unchanged.
+func TiltFromVertical(pitch, roll float64) float64 {
+	up := math.Sin(toRadians(pitch)) // vertical component of the unit axis
+	return toDegrees(math.Acos(math.Max(-1, math.Min(1, up))))
+}
+
+// wrapDegrees normalizes an angle to [0, 360).
+func wrapDegrees(v float64) float64 {
+	v = math.Mod(v, 360)
+	if v < 0 {
+		v += 360
+	}
+	return v
+}
+
+// angularRate derives an angular rate in degrees per second between two
+// packets, given the angle selected by angle.
+func angularRate(prev, curr TelemetryPacket, angle func(TelemetryPacket) float64) float64 {
+	dt := (curr.Timestamp - prev.Timestamp) / 1000
+	if dt <= 0 {
+		return 0
+	}
+	return AngularDelta(angle(prev), angle(curr)) / dt
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatAttitude(t *testing.T) {
+	tests := []struct {
+		precision int
+		want      string
+	}{
+		{1, "P:90.0 R:-12.3 Y:359.9"},
+		{0, "P:90 R:-12 Y:360"},
+		{3, "P:90.000 R:-12.345 Y:359.950"},
+		{-1, "P:90 R:-12 Y:360"},
+	}
+	for _, tt := range tests {
+		if got := FormatAttitude(90, -12.345, 359.95, tt.precision); got != tt.want {
+			t.Errorf("precision %d: got %q, want %q", tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestAngularDelta(t *testing.T) {
+	tests := []struct {
+		prev, curr, want float64
+	}{
+		{10, 20, 10},
+		{20, 10, -10},
+		{359, 1, 2},
+		{1, 359, -2},
+		{0, 180, 180},
+		{90, 810, 0},
+		{-170, 170, -20},
+	}
+	for _, tt := range tests {
+		if got := AngularDelta(tt.prev, tt.curr); got != tt.want {
+			t.Errorf("AngularDelta(%v, %v) = %v, want %v", tt.prev, tt.curr, got, tt.want)
+		}
+	}
+}
+
+func TestTiltFromVertical(t *testing.T) {
+	tests := []struct {
+		pitch, roll, want float64
+	}{
+		{90, 0, 0},
+		{90, 270, 0},
+		{85, 0, 5},
+		{85, 45, 5},
+		{0, 0, 90},
+		{100, 0, 10},
+		{-30, 0, 120},
+		{-90, 0, 180},
+	}
+	for _, tt := range tests {
+		if got := TiltFromVertical(tt.pitch, tt.roll); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("TiltFromVertical(%v, %v) = %v, want %v", tt.pitch, tt.roll, got, tt.want)
+		}
+	}
+}
+
+func TestAngularRateAcrossWrap(t *testing.T) {
+	prev := TelemetryPacket{Timestamp: 0, Roll: 350}
+	curr := TelemetryPacket{Timestamp: 500, Roll: 10}
+
+	if got := angularRate(prev, curr, func(p TelemetryPacket) float64 { return p.Roll }); got != 40 {
+		t.Fatalf("got %v deg/s, want 40", got)
+	}
+}
@@ -0,0 +1,212 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// columnarMagic starts a columnar capture file, followed by a version byte.
+const (
+	columnarMagic   = "RTCOL"
+	columnarVersion = 1
+)
+
+// maxColumnarBlock caps the packets in a block, over 100 hours at 10 Hz.
+const maxColumnarBlock = 1 << 22
+
+// columnarRowBytes is the size in a block of one packet: the float columns
+// and velocity, then seed, signal, state and flags.
+var columnarRowBytes = (len(floatColumns)+1)*8 + 8 + 4 + 1 + 1
+
+// errColumnarBlockSize reports a block count over maxColumnarBlock.
+var errColumnarBlockSize = errors.New("columnar block too large")
+
+// The columnar capture format stores packets in blocks, each self-contained
+// so a recording can append to the file block by block. Everything is little
+// endian:
+//
+//	header  "RTCOL" magic, uint8 version (1)
+//	block   uint32 packet count n, then n values of each column in order:
+//	        timestamp, pitch, roll, yaw, gforce, altitude, latitude,
+//	        longitude, loopsPerSecond, velocity  float64
+//	        seed                                 int64
+//	        signal                               int32
+//	        state                                int8
+//	        flags                                uint8
+//
+// The flags are bit 0 gpsNoFix, 1 deadReckoned, 2 sensorFailed, 3
+// gforceClamped, 4 armed and 5 set when the packet has a velocity. Fields
+// derived while streaming, such as source and receivers, are not stored. A
+// block holds at most maxColumnarBlock packets.
+const (
+	flagGPSNoFix = 1 << iota
+	flagDeadReckoned
+	flagSensorFailed
+	flagGForceClamped
+	flagArmed
+	flagVelocity
+)
+
+// floatColumns are the float64 columns of a block, in order.
+var floatColumns = []func(*TelemetryPacket) *float64{
+	func(p *TelemetryPacket) *float64 { return &p.Timestamp },
+	func(p *TelemetryPacket) *float64 { return &p.Pitch },
+	func(p *TelemetryPacket) *float64 { return &p.Roll },
+	func(p *TelemetryPacket) *float64 { return &p.Yaw },
+	func(p *TelemetryPacket) *float64 { return &p.GForce },
+	func(p *TelemetryPacket) *float64 { return &p.Altitude },
+	func(p *TelemetryPacket) *float64 { return &p.GPS.Latitude },
+	func(p *TelemetryPacket) *float64 { return &p.GPS.Longitude },
+	func(p *TelemetryPacket) *float64 { return &p.LoopsPerSecond },
+}
+
+// isColumnar reports whether a capture starts with the columnar header.
+func isColumnar(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(columnarMagic))
+}
+
+// MarshalColumnar encodes packets as a columnar capture, in as few blocks as
+// maxColumnarBlock allows.
+func MarshalColumnar(packets []TelemetryPacket) []byte {
+	var b bytes.Buffer
+	b.WriteString(columnarMagic)
+	b.WriteByte(columnarVersion)
+	for len(packets) > maxColumnarBlock {
+		writeColumnarBlock(&b, packets[:maxColumnarBlock])
+		packets = packets[maxColumnarBlock:]
+	}
+	writeColumnarBlock(&b, packets)
+	return b.Bytes()
+}
+
+// writeColumnarBlock appends one block of packets.
+func writeColumnarBlock(b *bytes.Buffer, packets []TelemetryPacket) {
+	n := len(packets)
+	put := func(v any) { _ = binary.Write(b, binary.LittleEndian, v) } // a bytes.Buffer does not fail
+	put(uint32(n))
+
+	floats := make([]float64, n)
+	for _, column := range floatColumns {
+		for i := range packets {
+			floats[i] = *column(&packets[i])
+		}
+		put(floats)
+	}
+	seeds := make([]int64, n)
+	signals := make([]int32, n)
+	states := make([]int8, n)
+	flags := make([]uint8, n)
+	for i, p := range packets {
+		floats[i] = 0
+		if p.Velocity != nil {
+			floats[i] = *p.Velocity
+			flags[i] |= flagVelocity
+		}
+		seeds[i], signals[i], states[i] = p.Seed, int32(p.Signal), int8(p.State)
+		for _, f := range []struct {
+			set  bool
+			flag uint8
+		}{
+			{p.GPSNoFix, flagGPSNoFix}, {p.DeadReckoned, flagDeadReckoned}, {p.SensorFailed, flagSensorFailed},
+			{p.GForceClamped, flagGForceClamped}, {p.Armed, flagArmed},
+		} {
+			if f.set {
+				flags[i] |= f.flag
+			}
+		}
+	}
+	put(floats)
+	put(seeds)
+	put(signals)
+	put(states)
+	put(flags)
+}
+
+// ReadColumnar decodes a columnar capture. A block cut short, as by a
+// recording interrupted mid-write, or with a corrupt count over
+// maxColumnarBlock ends the capture: the blocks before it are returned.
+func ReadColumnar(r io.Reader) ([]TelemetryPacket, error) {
+	header := make([]byte, len(columnarMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil || !isColumnar(header) {
+		return nil, errors.New("not a columnar capture")
+	}
+	if v := header[len(columnarMagic)]; v != columnarVersion {
+		return nil, fmt.Errorf("unsupported columnar capture version %d", v)
+	}
+
+	var packets []TelemetryPacket
+	for {
+		var n uint32
+		err := binary.Read(r, binary.LittleEndian, &n)
+		if err == io.EOF {
+			return packets, nil
+		}
+		var block []TelemetryPacket
+		if err == nil {
+			block, err = readColumnarBlock(r, int(n))
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errColumnarBlockSize) {
+			log.DefaultLogger.Warn("Columnar capture ends in a partial block", "packets", len(packets))
+			return packets, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("read block: %w", err)
+		}
+		packets = append(packets, block...)
+	}
+}
+
+func readColumnarBlock(r io.Reader, n int) ([]TelemetryPacket, error) {
+	if n > maxColumnarBlock {
+		return nil, errColumnarBlockSize
+	}
+	// Read the block before allocating for its packets, so a corrupt count
+	// takes no more memory than the rest of the capture.
+	size := int64(n) * int64(columnarRowBytes)
+	block, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(block)) < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	r = bytes.NewReader(block)
+
+	packets := make([]TelemetryPacket, n)
+	floats := make([]float64, n)
+	for _, column := range floatColumns {
+		if err := binary.Read(r, binary.LittleEndian, floats); err != nil {
+			return nil, err
+		}
+		for i := range packets {
+			*column(&packets[i]) = floats[i]
+		}
+	}
+	seeds := make([]int64, n)
+	signals := make([]int32, n)
+	states := make([]int8, n)
+	flags := make([]uint8, n)
+	for _, column := range []any{floats, seeds, signals, states, flags} {
+		if err := binary.Read(r, binary.LittleEndian, column); err != nil {
+			return nil, err
+		}
+	}
+	for i := range packets {
+		p := &packets[i]
+		if flags[i]&flagVelocity != 0 {
+			v := floats[i]
+			p.Velocity = &v
+		}
+		p.Seed, p.Signal, p.State = seeds[i], int(signals[i]), RocketState(states[i])
+		p.GPSNoFix = flags[i]&flagGPSNoFix != 0
+		p.DeadReckoned = flags[i]&flagDeadReckoned != 0
+		p.SensorFailed = flags[i]&flagSensorFailed != 0
+		p.GForceClamped = flags[i]&flagGForceClamped != 0
+		p.Armed = flags[i]&flagArmed != 0
+	}
+	return packets, nil
+}
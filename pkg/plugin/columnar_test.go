@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestColumnarRoundTrips(t *testing.T) {
+	v := -12.5
+	packets := []TelemetryPacket{
+		{Signal: -71, Timestamp: 1000, Pitch: 88.5, Roll: 12, Yaw: 3, GForce: 4.25, Altitude: 120.5,
+			GPS: GPS{Latitude: 37.7749, Longitude: -122.4194}, State: LAUNCHING, LoopsPerSecond: 10,
+			Seed: 42, Armed: true, GForceClamped: true},
+		{Signal: -80, Timestamp: 1500, Altitude: 90, State: DESCENDING, Velocity: &v,
+			GPSNoFix: true, DeadReckoned: true, SensorFailed: true},
+	}
+	got, err := ReadColumnar(bytes.NewReader(MarshalColumnar(packets)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, packets) {
+		t.Errorf("read back %+v, want %+v", got, packets)
+	}
+}
+
+func TestColumnarReadsAppendedBlocks(t *testing.T) {
+	doc := bytes.NewBuffer(MarshalColumnar([]TelemetryPacket{{Timestamp: 1}}))
+	writeColumnarBlock(doc, []TelemetryPacket{{Timestamp: 2}, {Timestamp: 3}})
+	got, err := ReadColumnar(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[2].Timestamp != 3 {
+		t.Errorf("read %+v, want three packets over both blocks", got)
+	}
+}
+
+func TestColumnarDropsPartialBlock(t *testing.T) {
+	doc := bytes.NewBuffer(MarshalColumnar([]TelemetryPacket{{Timestamp: 1}}))
+	var tail bytes.Buffer
+	writeColumnarBlock(&tail, []TelemetryPacket{{Timestamp: 2}, {Timestamp: 3}})
+	for _, cut := range []int{2, tail.Len() - 3} {
+		partial := bytes.NewBuffer(append(bytes.Clone(doc.Bytes()), tail.Bytes()[:cut]...))
+		got, err := ReadColumnar(partial)
+		if err != nil {
+			t.Fatalf("cut at %d: %v", cut, err)
+		}
+		if len(got) != 1 || got[0].Timestamp != 1 {
+			t.Errorf("cut at %d: read %+v, want the complete first block", cut, got)
+		}
+	}
+}
+
+func TestColumnarDropsCorruptBlockCount(t *testing.T) {
+	doc := MarshalColumnar([]TelemetryPacket{{Timestamp: 1}})
+	for _, n := range []uint32{maxColumnarBlock, maxColumnarBlock + 1, math.MaxUint32} {
+		corrupt := binary.LittleEndian.AppendUint32(bytes.Clone(doc), n)
+		corrupt = append(corrupt, make([]byte, 64)...)
+		got, err := ReadColumnar(bytes.NewReader(corrupt))
+		if err != nil {
+			t.Fatalf("count %d: %v", n, err)
+		}
+		if len(got) != 1 || got[0].Timestamp != 1 {
+			t.Errorf("count %d: read %+v, want the complete first block", n, got)
+		}
+	}
+}
+
+func TestColumnarRejectsBadInput(t *testing.T) {
+	full := MarshalColumnar([]TelemetryPacket{{Timestamp: 1}})
+	version := append([]byte(nil), full...)
+	version[len(columnarMagic)] = 9
+	for name, doc := range map[string][]byte{
+		"csv":     []byte("Received - RSSI: -70, Message: 1000"),
+		"version": version,
+		"header":  full[:3],
+	} {
+		if _, err := ReadColumnar(bytes.NewReader(doc)); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package plugin
+
+import "math"
+
+// VelocityDivergence compares the average vertical speed implied by the
+// altitude change between two packets with their reported velocities. As long
+// as the velocity changes monotonically between packets, the average must lie
+// between the two reported values; the result is how far outside that range
+// it falls, in m/s. It is 0 when either packet carries no velocity.
+func VelocityDivergence(prev, curr TelemetryPacket) float64 {
+	if prev.Velocity == nil || curr.Velocity == nil {
+		return 0
+	}
+	dt := (curr.Timestamp - prev.Timestamp) / 1000
+	if dt <= 0 {
+		return 0
+	}
+	implied := (curr.Altitude - prev.Altitude) / dt
+	lo := math.Min(*prev.Velocity, *curr.Velocity)
+	hi := math.Max(*prev.Velocity, *curr.Velocity)
+	switch {
+	case implied < lo:
+		return lo - implied
+	case implied > hi:
+		return implied - hi
+	}
+	return 0
+}
+
+// ConsistencyScore maps a velocity divergence onto 1 (consistent) down to 0
+// at maxDivergence and beyond.
+func ConsistencyScore(divergence, maxDivergence float64) float64 {
+	if maxDivergence <= 0 {
+		return 1
+	}
+	return clamp(1-divergence/maxDivergence, 0, 1)
+}
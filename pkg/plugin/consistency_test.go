@@ -0,0 +1,98 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func velocityPacket(ts, altitude, velocity float64) TelemetryPacket {
+	return TelemetryPacket{Timestamp: ts, Altitude: altitude, Velocity: &velocity}
+}
+
+func TestVelocityDivergence(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		prev, curr TelemetryPacket
+		want       float64
+	}{
+		{"steady climb", velocityPacket(0, 100, 50), velocityPacket(1000, 150, 50), 0},
+		{"accelerating", velocityPacket(0, 0, 0), velocityPacket(1000, 40, 80), 0},
+		{"altitude spike", velocityPacket(0, 100, 50), velocityPacket(1000, 400, 50), 250},
+		{"stuck altimeter", velocityPacket(0, 100, -10), velocityPacket(1000, 100, -10), 10},
+		{"no velocity", TelemetryPacket{Timestamp: 0}, TelemetryPacket{Timestamp: 1000, Altitude: 500}, 0},
+	} {
+		if got := VelocityDivergence(tc.prev, tc.curr); got != tc.want {
+			t.Errorf("%s: divergence %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestConsistencyFlagsSensorFailure(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"dataInconsistent"}
+
+	cfg := models.DefaultPluginSettings().Simulation
+	start := time.UnixMilli(0)
+	for _, failure := range []bool{false, true} {
+		if failure {
+			cfg.SensorFailure = models.SensorFailureConfig{Field: "altitude", Mode: models.SensorStuck, After: 5}
+		}
+		sim, err := newSimulator(start, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+		flagged := false
+		for now := start; now.Sub(start) < 3*time.Minute; now = now.Add(500 * time.Millisecond) {
+			frame := b.Build(sim.TickAt(now))
+			if v, ok := frame.Fields[1].ConcreteAt(0); ok && v.(bool) {
+				flagged = true
+			}
+		}
+		if flagged != failure {
+			t.Errorf("sensor failure %v: flagged inconsistent %v", failure, flagged)
+		}
+	}
+}
+
+func TestConsistencyNullWithoutVelocity(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"dataConsistency", "dataInconsistent"}
+	b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+	// Hardware that does not report a velocity cannot be checked.
+	for i, packet := range []TelemetryPacket{
+		{Timestamp: 0, Altitude: 100},
+		{Timestamp: 1000, Altitude: 400},
+		velocityPacket(2000, 450, 50),
+		velocityPacket(3000, 500, 50),
+	} {
+		frame := b.Build(packet)
+		score, _ := frame.FieldByName("dataConsistency")
+		flag, _ := frame.FieldByName("dataInconsistent")
+		_, scored := score.ConcreteAt(0)
+		_, flagged := flag.ConcreteAt(0)
+		if want := i == 3; scored != want || flagged != want {
+			t.Errorf("packet %d: score set %v, flag set %v, want %v", i, scored, flagged, want)
+		}
+	}
+}
+
+func TestParseVelocityColumn(t *testing.T) {
+	packet, err := ParsePacket("Received - RSSI: -70, Message: 1000,0,0,0,1,120,37.1,-122.1,LAUNCHING,10,48.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packet.Velocity == nil || *packet.Velocity != 48.5 {
+		t.Errorf("velocity %v, want 48.5", packet.Velocity)
+	}
+	if packet, _ := ParsePacket("Received - RSSI: -70, Message: 1000,0,0,0,1,120,37.1,-122.1,LAUNCHING,10"); packet.Velocity != nil {
+		t.Errorf("velocity %v without the column, want none", *packet.Velocity)
+	}
+	if _, err := ParsePacket("Received - RSSI: -70, Message: 1000,0,0,0,1,120,37.1,-122.1,LAUNCHING,10,fast"); err == nil {
+		t.Error("want an error for an unparseable velocity")
+	}
+}
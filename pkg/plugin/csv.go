@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// radioCSVHeader names the columns of the radio packet format.
+const radioCSVHeader = "timestamp,pitch,roll,yaw,gforce,altitude,lat,lon,state,loops"
+
+// MarshalRadioCSV writes packets as radio lines, with their RSSI, that
+// ParsePacket reads back. Packets with a velocity carry it as an eleventh
+// column. A header row breaks that round trip, so it is for tools that need
+// one.
+func MarshalRadioCSV(packets []TelemetryPacket, header bool) []byte {
+	var b bytes.Buffer
+	if header {
+		b.WriteString(radioCSVHeader)
+		for _, p := range packets {
+			if p.Velocity != nil {
+				b.WriteString(",velocity")
+				break
+			}
+		}
+		b.WriteString("\n")
+	}
+	for _, p := range packets {
+		fmt.Fprintf(&b, "Received - RSSI: %d, Message: %s,%s,%s,%s,%s,%s,%s,%s,%s,%s",
+			p.Signal, formatDecimal(p.Timestamp), formatDecimal(p.Pitch), formatDecimal(p.Roll), formatDecimal(p.Yaw),
+			formatDecimal(p.GForce), formatDecimal(p.Altitude), formatDecimal(p.GPS.Latitude), formatDecimal(p.GPS.Longitude),
+			stateName(p.State), formatDecimal(p.LoopsPerSecond))
+		if p.Velocity != nil {
+			b.WriteString("," + formatDecimal(*p.Velocity))
+		}
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// MarshalFrameCSV writes a frame as CSV with one column per field, optionally
+// under a header row of field names. Times are RFC 3339 and nulls are empty.
+func MarshalFrameCSV(frame *data.Frame, header bool) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if header {
+		names := make([]string, len(frame.Fields))
+		for i, f := range frame.Fields {
+			names[i] = f.Name
+		}
+		if err := w.Write(names); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, _ := frame.RowLen()
+	record := make([]string, len(frame.Fields))
+	for row := 0; row < rows; row++ {
+		for i, f := range frame.Fields {
+			record[i] = csvValue(f, row)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+func csvValue(f *data.Field, row int) string {
+	v, ok := f.ConcreteAt(row)
+	if !ok {
+		return ""
+	}
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return formatDecimal(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,76 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestRadioCSVRoundTrips(t *testing.T) {
+	packets := []TelemetryPacket{
+		{Signal: -71, Timestamp: 1000, Pitch: 88.5, Roll: 12, Yaw: 3, GForce: 4.25, Altitude: 120.5,
+			GPS: GPS{Latitude: 37.7749, Longitude: -122.4194}, State: LAUNCHING, LoopsPerSecond: 10},
+		{Signal: -80, Timestamp: 1500, Altitude: 90, State: DESCENDING, LoopsPerSecond: 10, Velocity: new(float64)},
+	}
+	*packets[1].Velocity = -9.5
+	doc := MarshalRadioCSV(packets, false)
+
+	lines := strings.Split(strings.TrimSpace(string(doc)), "\n")
+	if len(lines) != len(packets) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(packets))
+	}
+	for i, line := range lines {
+		got, err := ParsePacket(line)
+		if err != nil {
+			t.Fatalf("line %d does not parse back: %v", i, err)
+		}
+		if !reflect.DeepEqual(*got, packets[i]) {
+			t.Errorf("line %d parsed as %+v, want %+v", i, *got, packets[i])
+		}
+	}
+
+	if header := MarshalRadioCSV(packets, true); !strings.HasPrefix(string(header), radioCSVHeader+",velocity\n") {
+		t.Errorf("header requested but missing: %.60s", header)
+	}
+}
+
+func TestFlightExportCSV(t *testing.T) {
+	dir := t.TempDir()
+	writeFlight(t, dir, "alpha.log", 0, 100)
+	settings := models.DefaultPluginSettings()
+	settings.FlightDir = dir
+	d := &Datasource{settings: settings}
+
+	get := func(query string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/flight/alpha.csv"+query, nil)
+		req.SetPathValue("file", "alpha.csv")
+		rec := httptest.NewRecorder()
+		d.handleFlightExport(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	code, body := get("?fields=altitude,state")
+	if code != http.StatusOK {
+		t.Fatalf("got status %d: %s", code, body)
+	}
+	want := "time,altitude,state\n1970-01-01T00:00:00Z,0,1\n1970-01-01T00:00:01Z,100,1\n"
+	if body != want {
+		t.Errorf("got\n%s\nwant\n%s", body, want)
+	}
+
+	if _, body := get("?fields=altitude&header=false"); strings.HasPrefix(body, "time") {
+		t.Errorf("header=false still wrote a header:\n%s", body)
+	}
+	if _, body := get("?format=radio"); !strings.HasPrefix(body, "Received - RSSI: -50, Message: 0,") {
+		t.Errorf("radio export starts %.40q, want a radio line", body)
+	}
+	for _, query := range []string{"?format=xml", "?header=maybe", "?fields=warp"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", query, code)
+		}
+	}
+}
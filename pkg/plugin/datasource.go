@@ -2,10 +2,11 @@ package plugin
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
+	"sync"
 
+	"github.com/dibes/rocket-telemtry/pkg/models"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -21,16 +22,66 @@ var (
 	_ backend.CheckHealthHandler    = (*Datasource)(nil)
 	_ instancemgmt.InstanceDisposer = (*Datasource)(nil)
 	_ backend.StreamHandler         = (*Datasource)(nil)
+	_ backend.CallResourceHandler   = (*Datasource)(nil)
 )
 
 // NewDatasource creates a new datasource instance.
-func NewDatasource(_ context.Context, _ backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
-	return &Datasource{}, nil
+func NewDatasource(_ context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+	config, err := models.LoadPluginSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := newRecorder(config.Recording, config.Parser.TimeUnit)
+	if err != nil {
+		return nil, err
+	}
+	d := &Datasource{
+		settings:    *config,
+		parseErrors: newParseErrorLog(config.ErrorLogSize),
+		sims:        &simRegistry{},
+		recorder:    rec,
+	}
+	d.resources = d.newResourceHandler()
+	return d, nil
 }
 
 // Datasource is an example datasource which can respond to data queries, reports
 // its health and has streaming skills.
-type Datasource struct{}
+type Datasource struct {
+	settings  models.PluginSettings
+	resources backend.CallResourceHandler
+
+	parseErrors *parseErrorLog
+	sims        *simRegistry
+	recorder    *recorder
+
+	mu      sync.Mutex
+	latest  *TelemetryPacket
+	landing *landingEstimate
+}
+
+// recordPacket remembers the most recent packet for the resource endpoints.
+func (d *Datasource) recordPacket(packet TelemetryPacket) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.latest = &packet
+}
+
+// recordLanding remembers the most recent landing estimate of a stream.
+func (d *Datasource) recordLanding(est landingEstimate) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.landing = &est
+}
+
+func (d *Datasource) latestPacket() (TelemetryPacket, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.latest == nil {
+		return TelemetryPacket{}, false
+	}
+	return *d.latest, true
+}
 
 // PublishStream implements backend.StreamHandler.
 func (d *Datasource) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
@@ -40,67 +91,57 @@ func (d *Datasource) PublishStream(ctx context.Context, req *backend.PublishStre
 }
 
 func (d *Datasource) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
-	q := Query{}
-	json.Unmarshal(req.Data, &q)
+	q, err := parseQuery(req.Data)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
 
 	log.DefaultLogger.Info("Starting stream", "fields", q.Fields)
 
-	sim := NewRocketSimulation()
+	source, err := d.newSource(q)
+	if err != nil {
+		return err
+	}
+
+	packets := make(chan TelemetryPacket)
+	sourceErr := make(chan error, 1)
+	go func() {
+		sourceErr <- source.Run(ctx, packets)
+	}()
 
-	ticker := time.NewTicker(time.Duration(500) * time.Millisecond)
-	defer ticker.Stop()
+	builder := newFrameBuilder(q, d.settings)
+	events := &eventTracker{safety: d.settings.Safety}
+	landing := &landingTracker{settings: d.settings}
 
-	// Helper to check if a field is requested
-	shouldInclude := func(field string) bool {
-		if len(q.Fields) == 0 {
-			return true // Default to all if none specified
-		}
-		for _, f := range q.Fields {
-			if f == field {
-				return true
-			}
-		}
-		return false
+	var delta *frameDelta
+	if q.OmitUnchanged && q.QueryType != QueryTypeEvents {
+		delta = newFrameDelta(q.KeyframeInterval)
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-ticker.C:
-			packet := sim.Tick()
-
-			frame := data.NewFrame("response")
-
-			// Always include time
-			frame.Fields = append(frame.Fields, data.NewField("time", nil, []time.Time{time.UnixMilli(int64(packet.Timestamp))}))
-
-			if shouldInclude("altitude") {
-				frame.Fields = append(frame.Fields, data.NewField("altitude", nil, []float64{packet.Altitude}))
-			}
-			if shouldInclude("latitude") {
-				frame.Fields = append(frame.Fields, data.NewField("latitude", nil, []float64{packet.GPS.Latitude}))
-			}
-			if shouldInclude("longitude") {
-				frame.Fields = append(frame.Fields, data.NewField("longitude", nil, []float64{packet.GPS.Longitude}))
-			}
-			if shouldInclude("state") {
-				frame.Fields = append(frame.Fields, data.NewField("state", nil, []int64{int64(packet.State)}))
-			}
-			if shouldInclude("pitch") {
-				frame.Fields = append(frame.Fields, data.NewField("pitch", nil, []float64{packet.Pitch}))
-			}
-			if shouldInclude("roll") {
-				frame.Fields = append(frame.Fields, data.NewField("roll", nil, []float64{packet.Roll}))
+		case err := <-sourceErr:
+			if err != nil {
+				log.DefaultLogger.Error("Telemetry source stopped", "error", err)
 			}
-			if shouldInclude("yaw") {
-				frame.Fields = append(frame.Fields, data.NewField("yaw", nil, []float64{packet.Yaw}))
+			return err
+		case packet := <-packets:
+			d.recordPacket(packet)
+			if est, ok := landing.Update(packet); ok {
+				d.recordLanding(est)
 			}
-			if shouldInclude("gforce") {
-				frame.Fields = append(frame.Fields, data.NewField("gforce", nil, []float64{packet.GForce}))
+			var frame *data.Frame
+			if q.QueryType == QueryTypeEvents {
+				if frame = eventsFrame(events.Update(packet)); frame.Rows() == 0 {
+					continue
+				}
+			} else {
+				frame = builder.Build(packet)
 			}
-			if shouldInclude("signal") {
-				frame.Fields = append(frame.Fields, data.NewField("signal", nil, []int64{int64(packet.Signal)}))
+			if delta != nil {
+				frame = delta.Apply(frame)
 			}
 
 			err := sender.SendFrame(frame, data.IncludeAll)
@@ -123,7 +164,9 @@ func (d *Datasource) SubscribeStream(ctx context.Context, req *backend.Subscribe
 // created. As soon as datasource settings change detected by SDK old datasource instance will
 // be disposed and a new one will be created using NewSampleDatasource factory function.
 func (d *Datasource) Dispose() {
-	// Clean up datasource instance resources.
+	if err := d.recorder.Close(); err != nil {
+		log.DefaultLogger.Error("Failed to close recording", "error", err)
+	}
 }
 
 // QueryData handles multiple queries and returns multiple responses.
@@ -146,32 +189,61 @@ func (d *Datasource) QueryData(ctx context.Context, req *backend.QueryDataReques
 	return response, nil
 }
 
-type queryModel struct{}
-
 func (d *Datasource) query(_ context.Context, pCtx backend.PluginContext, query backend.DataQuery) backend.DataResponse {
 	var response backend.DataResponse
 
-	// Unmarshal the JSON into our queryModel.
-	var qm queryModel
+	// Unmarshal the JSON into our Query.
+	q, err := parseQuery(query.JSON)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid query: %v", err.Error()))
+	}
 
-	err := json.Unmarshal(query.JSON, &qm)
+	packets, err := loadHistory(d.settings, query.TimeRange)
+	if errors.Is(err, errNoHistory) {
+		frame := data.NewFrame("response")
+		frame.Meta = &data.FrameMeta{Notices: []data.Notice{{Severity: data.NoticeSeverityInfo, Text: err.Error()}}}
+		response.Frames = append(response.Frames, frame)
+		return response
+	}
 	if err != nil {
-		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("json unmarshal: %v", err.Error()))
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("load history: %v", err.Error()))
+	}
+
+	packets = filterPackets(packets, q.keepHistory)
+	if q.QueryType == QueryTypeEvents {
+		events := &eventTracker{safety: d.settings.Safety}
+		var eventLog []flightEvent
+		for _, p := range packets {
+			eventLog = append(eventLog, events.Update(p)...)
+		}
+		response.Frames = append(response.Frames, eventsFrame(eventLog))
+		return response
+	}
+	// The warmup is left out of the summary and the rows, but still runs
+	// through the frame builder so its filters have converged.
+	warmup := q.WarmupDiscard.Count(packets)
+	summary := SummarizeFlight(Flight{Packets: packets[warmup:]}, d.settings.RailLength)
+
+	maxPoints := q.MaxPoints
+	if maxPoints == 0 {
+		maxPoints = int(query.MaxDataPoints)
+	}
+	keep := make([]bool, len(packets))
+	decimated := decimation(packets[warmup:], maxPoints)
+	for i := warmup; i < len(packets); i++ {
+		keep[i] = decimated == nil || decimated[i-warmup]
 	}
 
 	// create data frame response.
 	// For an overview on data frames and how grafana handles them:
 	// https://grafana.com/developers/plugin-tools/introduction/data-frames
-	frame := data.NewFrame("response")
-
-	// add fields.
-	frame.Fields = append(frame.Fields,
-		data.NewField("time", nil, []time.Time{query.TimeRange.From, query.TimeRange.To}),
-		data.NewField("values", nil, []int64{10, 20}),
-	)
+	frame := newFrameBuilder(q, d.settings).BuildRows(packets, keep)
 
 	// add the frames to the response.
 	response.Frames = append(response.Frames, frame)
+	if q.Summary {
+		response.Frames = append(response.Frames, summaryFrame(summary))
+	}
 
 	return response
 }
@@ -181,6 +253,19 @@ func (d *Datasource) query(_ context.Context, pCtx backend.PluginContext, query
 // datasource configuration page which allows users to verify that
 // a datasource is working as expected.
 func (d *Datasource) CheckHealth(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	// Serial line settings that do not match the device garble every byte,
+	// which would otherwise only surface as parse errors once streaming.
+	for i, cfg := range d.settings.SourceConfigs() {
+		if cfg.Type != models.SourceSerial {
+			continue
+		}
+		if _, err := serialConfig(cfg.Serial); err != nil {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: fmt.Sprintf("source %s: %v", sourceName(cfg, i), err),
+			}, nil
+		}
+	}
 	return &backend.CheckHealthResult{
 		Status:  backend.HealthStatusOk,
 		Message: "Data source is working",
@@ -2,9 +2,15 @@ package plugin
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/dibes/rocket-telemtry/pkg/models"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 func TestQueryData(t *testing.T) {
@@ -26,3 +32,250 @@ func TestQueryData(t *testing.T) {
 		t.Fatal("QueryData must return a response")
 	}
 }
+
+func TestQueryDataSerial(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	settings.Source = models.SourceSerial
+	from := time.UnixMilli(0)
+	query := func(settings models.PluginSettings) backend.DataResponse {
+		ds := Datasource{settings: settings}
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      []byte(`{"fields":["altitude"]}`),
+				TimeRange: backend.TimeRange{From: from, To: from.Add(time.Minute)},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Responses["A"]
+	}
+
+	// Without a recording there is no history, which the panel is told.
+	res := query(settings)
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if len(res.Frames) != 1 || res.Frames[0].Meta == nil || len(res.Frames[0].Meta.Notices) != 1 {
+		t.Fatalf("got frames %v, want one empty frame with a notice", res.Frames)
+	}
+
+	// Nothing recorded yet is an empty history.
+	settings.Recording = models.RecordingConfig{File: filepath.Join(t.TempDir(), "serial.log"), Format: models.FormatCSV}
+	if res := query(settings); res.Error != nil || res.Frames[0].Rows() != 0 {
+		t.Fatalf("before recording: got %v rows, error %v", res.Frames[0].Rows(), res.Error)
+	}
+
+	// With packets recorded from the port, history reads the recording.
+	packets := []TelemetryPacket{{Timestamp: 1000, Altitude: 10, State: LANDED}, {Timestamp: 2000, Altitude: 20, State: LANDED}}
+	if err := os.WriteFile(settings.Recording.File, MarshalRadioCSV(packets, false), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res = query(settings)
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if altitude, _ := res.Frames[0].FieldByName("altitude"); altitude == nil || altitude.Len() != 2 {
+		t.Fatalf("got %v, want the two recorded packets", res.Frames[0])
+	}
+}
+
+func TestQueryDataMinAltitude(t *testing.T) {
+	ds := Datasource{settings: models.DefaultPluginSettings()}
+	from := time.UnixMilli(0)
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      []byte(`{"fields":["altitude"],"minAltitude":10,"maxPoints":100000}`),
+			TimeRange: backend.TimeRange{From: from, To: from.Add(5 * time.Minute)},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := resp.Responses["A"]
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	altitude, _ := res.Frames[0].FieldByName("altitude")
+	if altitude == nil || altitude.Len() == 0 {
+		t.Fatal("no airborne rows returned")
+	}
+	for i := 0; i < altitude.Len(); i++ {
+		if v := altitude.At(i).(float64); v < 10 {
+			t.Fatalf("row %d below minAltitude: %v", i, v)
+		}
+	}
+}
+
+func TestQueryDataWarmupDiscard(t *testing.T) {
+	ds := Datasource{settings: models.DefaultPluginSettings()}
+	from := time.UnixMilli(0)
+	query := func(warmup string) *data.Frame {
+		t.Helper()
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      []byte(`{"fields":["filteredAltitude"],"maxPoints":100000` + warmup + `}`),
+				TimeRange: backend.TimeRange{From: from, To: from.Add(time.Minute)},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := resp.Responses["A"]; res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		return resp.Responses["A"].Frames[0]
+	}
+
+	full := query("")
+	for _, tc := range []struct {
+		warmup string
+		skip   int
+	}{
+		{warmup: `,"warmupDiscard":{"samples":20}`, skip: 20},
+		{warmup: `,"warmupDiscard":{"seconds":10}`, skip: 20}, // 500ms ticks
+		{warmup: `,"warmupDiscard":{"seconds":1,"samples":20}`, skip: 20},
+	} {
+		trimmed := query(tc.warmup)
+		if got, want := trimmed.Rows(), full.Rows()-tc.skip; got != want {
+			t.Fatalf("%s: got %d rows, want %d", tc.warmup, got, want)
+		}
+		// The filter ran over the discarded rows: the rest is unchanged.
+		for i := 0; i < trimmed.Rows(); i++ {
+			if got, want := trimmed.Fields[1].At(i), full.Fields[1].At(i+tc.skip); got != want {
+				t.Fatalf("%s: row %d filtered altitude %v, want %v", tc.warmup, i, got, want)
+			}
+		}
+	}
+}
+
+func TestQueryDataWarmupSummary(t *testing.T) {
+	ds := Datasource{settings: models.DefaultPluginSettings()}
+	from := time.UnixMilli(0)
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      []byte(`{"fields":["altitude"],"summary":true,"maxPoints":10,"warmupDiscard":{"seconds":30}}`),
+			TimeRange: backend.TimeRange{From: from, To: from.Add(2 * time.Minute)},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := resp.Responses["A"]
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	// Past apogee by 30s, the summary's apogee is the first row kept.
+	rows, summary := res.Frames[0], res.Frames[1]
+	first := rows.Fields[1].At(0).(float64)
+	if got := summary.Fields[0].At(0).(float64); got != first {
+		t.Fatalf("summary apogee %v, want %v from the first row after the warmup", got, first)
+	}
+	if rows.Rows() > 12 {
+		t.Fatalf("got %d rows, want the warmup discarded before decimating to 10", rows.Rows())
+	}
+}
+
+func TestQueryDataDecimatedLoss(t *testing.T) {
+	ds := Datasource{settings: models.DefaultPluginSettings()}
+	from := time.UnixMilli(0)
+	query := func(maxPoints int) *data.Frame {
+		t.Helper()
+		resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      []byte(fmt.Sprintf(`{"fields":["packetLossPercent"],"maxPoints":%d}`, maxPoints)),
+				TimeRange: backend.TimeRange{From: from, To: from.Add(10 * time.Minute)},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := resp.Responses["A"]; res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		return resp.Responses["A"].Frames[0]
+	}
+
+	full, decimated := query(100000), query(100)
+	if decimated.Rows() >= full.Rows() {
+		t.Fatalf("got %d decimated rows of %d, want fewer", decimated.Rows(), full.Rows())
+	}
+	loss := make(map[time.Time]float64, full.Rows())
+	for i := 0; i < full.Rows(); i++ {
+		loss[full.Fields[0].At(i).(time.Time)] = full.Fields[1].At(i).(float64)
+	}
+	for i := 0; i < decimated.Rows(); i++ {
+		ts := decimated.Fields[0].At(i).(time.Time)
+		if got, want := decimated.Fields[1].At(i).(float64), loss[ts]; got != want {
+			t.Fatalf("row at %v: packet loss %v%%, want %v%% as undecimated", ts, got, want)
+		}
+	}
+}
+
+func TestCheckHealthSerial(t *testing.T) {
+	for _, tt := range []struct {
+		serial models.SerialConfig
+		want   backend.HealthStatus
+	}{
+		{models.SerialConfig{Device: "/dev/ttyUSB0"}, backend.HealthStatusOk},
+		{models.SerialConfig{Device: "/dev/ttyUSB0", Parity: "sometimes"}, backend.HealthStatusError},
+		{models.SerialConfig{Device: "/dev/ttyUSB0", Baud: 12345}, backend.HealthStatusError},
+		{models.SerialConfig{}, backend.HealthStatusError},
+	} {
+		settings := models.DefaultPluginSettings()
+		settings.Sources = []models.SourceConfig{
+			{Type: models.SourceSimulation},
+			{Name: "radio", Type: models.SourceSerial, Serial: tt.serial},
+		}
+		ds := Datasource{settings: settings}
+		res, err := ds.CheckHealth(context.Background(), &backend.CheckHealthRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != tt.want {
+			t.Errorf("%+v: status %v (%s), want %v", tt.serial, res.Status, res.Message, tt.want)
+		}
+	}
+}
+
+func TestQueryDataEvents(t *testing.T) {
+	ds := Datasource{settings: models.DefaultPluginSettings()}
+	from := time.UnixMilli(0)
+
+	resp, err := ds.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      []byte(`{"queryType":"events"}`),
+			TimeRange: backend.TimeRange{From: from, To: from.Add(5 * time.Minute)},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := resp.Responses["A"]
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if len(res.Frames) != 1 || res.Frames[0].Name != "events" {
+		t.Fatalf("got %d frames, want the events frame alone", len(res.Frames))
+	}
+
+	types, _ := res.Frames[0].FieldByName("type")
+	seen := map[string]bool{}
+	for i := 0; i < types.Len(); i++ {
+		seen[types.At(i).(string)] = true
+	}
+	for _, typ := range []string{"launch", "apex", "deploy", "land"} {
+		if !seen[typ] {
+			t.Errorf("no %s event in five minutes of simulated flights", typ)
+		}
+	}
+}
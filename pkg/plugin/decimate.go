@@ -0,0 +1,56 @@
+package plugin
+
+// Decimate reduces packets to roughly maxPoints rows by bucket sampling. Rows
+// where the state changes and the apogee row are always kept so event markers
+// survive downsampling, even if that means returning more than maxPoints.
+func Decimate(packets []TelemetryPacket, maxPoints int) []TelemetryPacket {
+	keep := decimation(packets, maxPoints)
+	if keep == nil {
+		return packets
+	}
+	out := make([]TelemetryPacket, 0, maxPoints)
+	for i, k := range keep {
+		if k {
+			out = append(out, packets[i])
+		}
+	}
+	return out
+}
+
+// decimation reports which packets Decimate keeps, or nil when it keeps
+// them all.
+func decimation(packets []TelemetryPacket, maxPoints int) []bool {
+	if maxPoints <= 0 || len(packets) <= maxPoints {
+		return nil
+	}
+
+	keep := make([]bool, len(packets))
+	keep[0] = true
+	keep[len(packets)-1] = true
+
+	apogee := 0
+	for i := range packets {
+		if i > 0 && packets[i].State != packets[i-1].State {
+			keep[i] = true
+		}
+		if packets[i].Altitude > packets[apogee].Altitude {
+			apogee = i
+		}
+	}
+	keep[apogee] = true
+
+	kept := 0
+	for _, k := range keep {
+		if k {
+			kept++
+		}
+	}
+
+	if budget := maxPoints - kept; budget > 0 {
+		bucket := (len(packets) + budget - 1) / budget
+		for i := 0; i < len(packets); i += bucket {
+			keep[i] = true
+		}
+	}
+	return keep
+}
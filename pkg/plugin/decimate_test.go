@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+func TestDecimateKeepsTransitionsAndApogee(t *testing.T) {
+	from := time.UnixMilli(0)
+	packets, err := simulateHistory(backend.TimeRange{From: from, To: from.Add(5 * time.Minute)}, models.DefaultPluginSettings().Simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var transitions []TelemetryPacket
+	apogee := packets[0]
+	for i, p := range packets {
+		if i > 0 && p.State != packets[i-1].State {
+			transitions = append(transitions, p)
+		}
+		if p.Altitude > apogee.Altitude {
+			apogee = p
+		}
+	}
+	if len(transitions) < 4 {
+		t.Fatalf("expected a full flight cycle, got %d transitions", len(transitions))
+	}
+
+	decimated := Decimate(packets, 20)
+	if len(decimated) > 20 {
+		t.Fatalf("got %d points, want at most 20", len(decimated))
+	}
+
+	present := map[float64]bool{}
+	for _, p := range decimated {
+		present[p.Timestamp] = true
+	}
+	for _, p := range transitions {
+		if !present[p.Timestamp] {
+			t.Errorf("transition to state %d at %v was dropped", p.State, p.Timestamp)
+		}
+	}
+	if !present[apogee.Timestamp] {
+		t.Errorf("apogee at %v was dropped", apogee.Timestamp)
+	}
+}
+
+func TestDecimateShortInput(t *testing.T) {
+	packets := []TelemetryPacket{{Timestamp: 1}, {Timestamp: 2}}
+	if got := Decimate(packets, 10); len(got) != 2 {
+		t.Fatalf("got %d points, want 2", len(got))
+	}
+}
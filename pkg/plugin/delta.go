@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"reflect"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// frameDelta drops fields whose value has not changed since the previous
+// frame to shrink stream payloads.
+//
+// Reconstruction contract: the time field is always sent. Any other field
+// missing from a frame holds the value it had in the last frame that carried
+// it. A field is sent in the first frame it appears in and whenever its value
+// changes, and every keyframeInterval frames every field is sent, so a late
+// subscriber is complete after at most one interval.
+type frameDelta struct {
+	keyframeInterval int
+	last             map[string]any
+	count            int
+
+	fullBytes, sentBytes int
+}
+
+func newFrameDelta(keyframeInterval int) *frameDelta {
+	if keyframeInterval < 1 {
+		keyframeInterval = 1
+	}
+	return &frameDelta{keyframeInterval: keyframeInterval, last: map[string]any{}}
+}
+
+// Apply returns frame with unchanged fields removed. frame must hold a
+// single row.
+func (d *frameDelta) Apply(frame *data.Frame) *data.Frame {
+	keyframe := d.count%d.keyframeInterval == 0
+	d.count++
+
+	out := data.NewFrame(frame.Name)
+	out.Meta = frame.Meta
+	for _, f := range frame.Fields {
+		v := f.CopyAt(0)
+		key := f.Name + f.Labels.String() // fields may share a name, e.g. per receiver
+		prev, seen := d.last[key]
+		d.last[key] = v
+
+		if f.Type() == data.FieldTypeTime || keyframe || !seen || !reflect.DeepEqual(prev, v) {
+			out.Fields = append(out.Fields, f)
+		}
+	}
+
+	d.measure(frame, out)
+	return out
+}
+
+// measure tracks payload sizes with and without the delta, logging the
+// average once per keyframe interval. It serializes both frames, so it only
+// runs with debug logging on.
+func (d *frameDelta) measure(full, sent *data.Frame) {
+	if level := log.DefaultLogger.Level(); level == log.NoLevel || level > log.Debug {
+		return
+	}
+	fullJSON, err := data.FrameToJSON(full, data.IncludeAll)
+	if err != nil {
+		return
+	}
+	sentJSON, err := data.FrameToJSON(sent, data.IncludeAll)
+	if err != nil {
+		return
+	}
+	d.fullBytes += len(fullJSON)
+	d.sentBytes += len(sentJSON)
+
+	if d.count%d.keyframeInterval == 0 {
+		n := d.keyframeInterval
+		log.DefaultLogger.Debug("Stream payload size",
+			"bytesPerFrame", d.sentBytes/n,
+			"uncompressedBytesPerFrame", d.fullBytes/n,
+		)
+		d.fullBytes, d.sentBytes = 0, 0
+	}
+}
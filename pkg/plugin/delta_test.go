@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func fieldNames(t *testing.T, packets []TelemetryPacket, delta *frameDelta) [][]string {
+	t.Helper()
+	q := defaultQuery()
+	q.Fields = []string{"altitude", "state"}
+	b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+	var out [][]string
+	for _, p := range packets {
+		var names []string
+		for _, f := range delta.Apply(b.Build(p)).Fields {
+			names = append(names, f.Name)
+		}
+		out = append(out, names)
+	}
+	return out
+}
+
+func TestFrameDelta(t *testing.T) {
+	packets := []TelemetryPacket{
+		{Timestamp: 0, Altitude: 0, State: LANDED},
+		{Timestamp: 500, Altitude: 0, State: LANDED},
+		{Timestamp: 1000, Altitude: 10, State: LAUNCHING},
+		{Timestamp: 1500, Altitude: 20, State: LAUNCHING},
+		{Timestamp: 2000, Altitude: 20, State: LAUNCHING}, // keyframe
+	}
+	want := [][]string{
+		{"time", "altitude", "state"},
+		{"time"},
+		{"time", "altitude", "state"},
+		{"time", "altitude"},
+		{"time", "altitude", "state"},
+	}
+
+	got := fieldNames(t, packets, newFrameDelta(4))
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("frame %d: got fields %v, want %v", i, got[i], want[i])
+		}
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Fatalf("frame %d: got fields %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package plugin
+
+import (
+	"math"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// stateDetector infers the flight phase from the altitude of consecutive
+// packets, the way a ground station without the flight computer's state
+// would have to.
+type stateDetector struct {
+	cfg   models.DetectionConfig
+	state RocketState
+	prev  *TelemetryPacket
+}
+
+func (d *stateDetector) Update(p TelemetryPacket) RocketState {
+	var vspeed float64
+	if d.prev != nil {
+		vspeed = verticalSpeed(*d.prev, p)
+	}
+	d.prev = &p
+
+	switch d.state {
+	case LANDED:
+		if vspeed > d.cfg.LaunchVelocity {
+			d.state = LAUNCHING
+		}
+	case LAUNCHING:
+		if vspeed <= 0 {
+			d.state = APEX
+		}
+	case APEX:
+		if vspeed < -d.cfg.DescentVelocity {
+			d.state = DESCENDING
+		}
+	case DESCENDING:
+		if p.Altitude <= d.cfg.LandedAltitude && math.Abs(vspeed) < d.cfg.LandedVelocity {
+			d.state = LANDED
+		}
+	}
+	return d.state
+}
+
+// stateDetection wraps a simulator to run the state detector on its packets,
+// leaving the true state in place for comparison.
+type stateDetection struct {
+	sim      Simulator
+	detector stateDetector
+}
+
+func (s *stateDetection) Unwrap() Simulator { return s.sim }
+
+func (s *stateDetection) TickAt(now time.Time) TelemetryPacket {
+	p := s.sim.TickAt(now)
+	detected := s.detector.Update(p)
+	p.DetectedState = &detected
+	return p
+}
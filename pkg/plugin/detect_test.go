@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestStateDetectionTracksTruth(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.Seed = 3
+	cfg.Detection.Enabled = true
+	start := time.UnixMilli(0)
+	sim, err := newSimulator(start, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every true phase change is detected within two ticks.
+	var packets []TelemetryPacket
+	for now := start; now.Sub(start) <= 2*time.Minute; now = now.Add(500 * time.Millisecond) {
+		packets = append(packets, sim.TickAt(now))
+	}
+	seen := map[RocketState]bool{}
+	for i, p := range packets {
+		if p.DetectedState == nil {
+			t.Fatalf("tick %d carries no detected state", i)
+		}
+		seen[*p.DetectedState] = true
+		if i < 2 || p.State != packets[i-2].State || p.State != packets[i-1].State {
+			continue
+		}
+		if *p.DetectedState != p.State {
+			t.Errorf("tick %d: detected %v, true state %v for 3 ticks", i, *p.DetectedState, p.State)
+		}
+	}
+	for _, state := range []RocketState{LAUNCHING, APEX, DESCENDING, LANDED} {
+		if !seen[state] {
+			t.Errorf("%v never detected", state)
+		}
+	}
+	if _, ok := rocketSimulation(sim); !ok {
+		t.Error("detection hides the physics simulation from control")
+	}
+}
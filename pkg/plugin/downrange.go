@@ -0,0 +1,47 @@
+package plugin
+
+import "math"
+
+// minTravelDistance is how far the rocket must have moved from the launch
+// origin before its direction of travel is taken as the launch azimuth.
+const minTravelDistance = 10.0 // m
+
+// DownrangeCrossrange splits a displacement of distance meters towards
+// bearing into its component along azimuth, and the one perpendicular to it,
+// positive to the right of the azimuth.
+func DownrangeCrossrange(distance, bearing, azimuth float64) (downrange, crossrange float64) {
+	off := toRadians(bearing - azimuth)
+	return distance * math.Cos(off), distance * math.Sin(off)
+}
+
+// rangeDecomposer measures the position against the launch azimuth: the
+// configured one, or the initial direction of travel of each flight.
+type rangeDecomposer struct {
+	azimuth *float64
+	travel  *float64
+	flight  int
+}
+
+// Update decomposes the last known position relative to the launch origin.
+// Until the direction of travel is known, the whole distance is downrange.
+func (r *rangeDecomposer) Update(o launchOrigin) (downrange, crossrange float64) {
+	if !o.set {
+		return 0, 0
+	}
+	if o.flight != r.flight {
+		r.flight, r.travel = o.flight, nil
+	}
+	distance := o.Distance()
+	bearing := Bearing(o.origin.Latitude, o.origin.Longitude, o.last.Latitude, o.last.Longitude)
+	azimuth := r.azimuth
+	if azimuth == nil {
+		if r.travel == nil && distance >= minTravelDistance {
+			r.travel = &bearing
+		}
+		azimuth = r.travel
+	}
+	if azimuth == nil {
+		return distance, 0
+	}
+	return DownrangeCrossrange(distance, bearing, *azimuth)
+}
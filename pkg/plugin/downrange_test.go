@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDownrangeCrossrange(t *testing.T) {
+	tests := []struct {
+		distance, bearing, azimuth float64
+		down, cross                float64
+	}{
+		{100, 90, 90, 100, 0},
+		{100, 180, 90, 0, 100},
+		{100, 0, 90, 0, -100},
+		{100, 270, 90, -100, 0},
+		{100, 45, 0, 70.71067811865476, 70.71067811865476},
+		{100, 350, 10, 93.96926207859084, -34.20201433256687},
+	}
+	for _, tt := range tests {
+		down, cross := DownrangeCrossrange(tt.distance, tt.bearing, tt.azimuth)
+		if math.Abs(down-tt.down) > 1e-9 || math.Abs(cross-tt.cross) > 1e-9 {
+			t.Errorf("DownrangeCrossrange(%v, %v, %v) = (%v, %v), want (%v, %v)",
+				tt.distance, tt.bearing, tt.azimuth, down, cross, tt.down, tt.cross)
+		}
+	}
+}
+
+func TestRangeDecomposer(t *testing.T) {
+	pad := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	at := func(north, east float64) GPS {
+		lat, lon := offsetLatLon(pad.Latitude, pad.Longitude, north, east)
+		return GPS{Latitude: lat, Longitude: lon}
+	}
+	east := 90.0
+
+	for _, tc := range []struct {
+		name    string
+		azimuth *float64
+		want    [][2]float64
+	}{
+		// The first 20 m eastwards set the azimuth, weathercocking north
+		// later shows up as negative crossrange.
+		{"learned", nil, [][2]float64{{0, 0}, {5, 0}, {20, 0}, {40, -30}}},
+		{"configured", &east, [][2]float64{{0, 0}, {3, -4}, {20, 0}, {40, -30}}},
+	} {
+		var o launchOrigin
+		r := rangeDecomposer{azimuth: tc.azimuth}
+		for i, fix := range []GPS{pad, at(4, 3), at(0, 20), at(30, 40)} {
+			o.Update(TelemetryPacket{GPS: fix, State: LAUNCHING})
+			down, cross := r.Update(o)
+			if math.Abs(down-tc.want[i][0]) > 0.1 || math.Abs(cross-tc.want[i][1]) > 0.1 {
+				t.Errorf("%s: fix %d: got (%.2f, %.2f), want %v", tc.name, i, down, cross, tc.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package plugin
+
+import "math"
+
+// ENU converts a position to east, north and up meters in the local tangent
+// plane at an origin. It is the inverse of offsetLatLon, and as flat over the
+// few kilometers of a flight.
+func ENU(origin GPS, originAlt float64, fix GPS, alt float64) (east, north, up float64) {
+	north = toRadians(fix.Latitude-origin.Latitude) * earthRadius
+	east = toRadians(fix.Longitude-origin.Longitude) * earthRadius * math.Cos(toRadians(origin.Latitude))
+	return east, north, alt - originAlt
+}
+
+// launchOrigin tracks the ENU origin of the current flight: the first fix
+// seen, then the pad position on each transition from the pad to LAUNCHING.
+// Packets without a fix hold the last known position.
+type launchOrigin struct {
+	origin, last GPS
+	altitude     float64
+	set          bool
+	prevState    RocketState
+	flight       int // counts the origins captured
+}
+
+// Update returns the packet's position relative to the launch origin.
+func (o *launchOrigin) Update(packet TelemetryPacket) (east, north, up float64) {
+	if !gpsLost(packet) {
+		o.last = packet.GPS
+		if !o.set || (onPad(o.prevState) && packet.State == LAUNCHING) {
+			o.origin, o.altitude, o.set = packet.GPS, packet.Altitude, true
+			o.flight++
+		}
+	}
+	o.prevState = packet.State
+	if !o.set {
+		return 0, 0, 0
+	}
+	return ENU(o.origin, o.altitude, o.last, packet.Altitude)
+}
+
+// Distance is the ground distance in meters from the launch origin to the
+// last known position.
+func (o *launchOrigin) Distance() float64 {
+	if !o.set {
+		return 0
+	}
+	return Haversine(o.origin.Latitude, o.origin.Longitude, o.last.Latitude, o.last.Longitude)
+}
+
+// pathTracker sums the ground track of the current flight from the launch
+// origin, fix to fix.
+type pathTracker struct {
+	flight int
+	last   GPS
+	length float64
+}
+
+// Update returns the length in meters of the ground track so far.
+func (p *pathTracker) Update(o launchOrigin) float64 {
+	if !o.set {
+		return 0
+	}
+	if o.flight != p.flight {
+		p.flight, p.last, p.length = o.flight, o.origin, 0
+	}
+	p.length += Haversine(p.last.Latitude, p.last.Longitude, o.last.Latitude, o.last.Longitude)
+	p.last = o.last
+	return p.length
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestENURoundTrip(t *testing.T) {
+	origin := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	lat, lon := offsetLatLon(origin.Latitude, origin.Longitude, 1200, -350)
+
+	east, north, up := ENU(origin, 10, GPS{Latitude: lat, Longitude: lon}, 510)
+	if math.Abs(east+350) > 1e-6 || math.Abs(north-1200) > 1e-6 || up != 500 {
+		t.Errorf("got ENU (%v, %v, %v), want (-350, 1200, 500)", east, north, up)
+	}
+}
+
+func TestLaunchOriginFollowsPad(t *testing.T) {
+	pad := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	lat, lon := offsetLatLon(pad.Latitude, pad.Longitude, 100, 0)
+	away := GPS{Latitude: lat, Longitude: lon}
+
+	var o launchOrigin
+	for i, tc := range []struct {
+		packet    TelemetryPacket
+		north, up float64
+	}{
+		{packet: TelemetryPacket{GPS: pad, State: LANDED}},
+		{packet: TelemetryPacket{GPS: pad, State: LAUNCHING}},
+		{packet: TelemetryPacket{GPS: away, Altitude: 300, State: LAUNCHING}, north: 100, up: 300},
+		{packet: TelemetryPacket{GPSNoFix: true, Altitude: 200, State: DESCENDING}, north: 100, up: 200},
+		// The next flight launches from where the last one landed.
+		{packet: TelemetryPacket{GPS: away, State: LANDED}, north: 100},
+		{packet: TelemetryPacket{GPS: away, Altitude: 5, State: LAUNCHING}},
+		// Through a hang fire, from back at the first pad.
+		{packet: TelemetryPacket{GPS: pad, Altitude: 5, State: LANDED}, north: -100},
+		{packet: TelemetryPacket{GPS: pad, Altitude: 5, State: IGNITION}, north: -100},
+		{packet: TelemetryPacket{GPS: pad, Altitude: 5, State: LAUNCHING}},
+	} {
+		east, north, up := o.Update(tc.packet)
+		if math.Abs(east) > 1e-6 || math.Abs(north-tc.north) > 1e-6 || up != tc.up {
+			t.Errorf("packet %d: got (%v, %v, %v), want north %v up %v", i, east, north, up, tc.north, tc.up)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// loggedParseError is a parse error with the time it was recorded.
+type loggedParseError struct {
+	PacketParseError
+	Time time.Time `json:"time"`
+}
+
+// parseErrorLog keeps the most recent parse errors in a fixed size ring.
+type parseErrorLog struct {
+	mu      sync.Mutex
+	entries []loggedParseError
+	next    int
+	full    bool
+	now     func() time.Time
+}
+
+func newParseErrorLog(size int) *parseErrorLog {
+	if size < 1 {
+		size = 1
+	}
+	return &parseErrorLog{entries: make([]loggedParseError, size), now: time.Now}
+}
+
+// Record stores err if it is a PacketParseError and ignores it otherwise.
+func (l *parseErrorLog) Record(err error) {
+	var perr *PacketParseError
+	if l == nil || !errors.As(err, &perr) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = loggedParseError{PacketParseError: *perr, Time: l.now()}
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent returns up to n errors, newest first. n <= 0 returns all of them.
+func (l *parseErrorLog) Recent(n int) []loggedParseError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+
+	out := make([]loggedParseError, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return out
+}
+
+// Reset discards all recorded errors.
+func (l *parseErrorLog) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.next = 0
+	l.full = false
+}
@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseErrorLogRing(t *testing.T) {
+	l := newParseErrorLog(3)
+	for i := 0; i < 5; i++ {
+		_, err := ParsePacket("bad line " + strconv.Itoa(i))
+		l.Record(err)
+	}
+	l.Record(errors.New("not a parse error"))
+
+	recent := l.Recent(0)
+	if len(recent) != 3 {
+		t.Fatalf("got %d errors, want 3", len(recent))
+	}
+	for i, want := range []string{"bad line 4", "bad line 3", "bad line 2"} {
+		if recent[i].Line != want {
+			t.Errorf("entry %d: got line %q, want %q", i, recent[i].Line, want)
+		}
+		if recent[i].Reason == "" {
+			t.Errorf("entry %d has no reason", i)
+		}
+	}
+
+	if got := l.Recent(1); len(got) != 1 || got[0].Line != "bad line 4" {
+		t.Errorf("Recent(1) = %+v, want the newest error", got)
+	}
+
+	l.Reset()
+	if got := l.Recent(0); len(got) != 0 {
+		t.Errorf("got %d errors after reset, want 0", len(got))
+	}
+}
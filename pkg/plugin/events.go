@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// flightEvent is one entry of the flight's event log.
+type flightEvent struct {
+	Time        time.Time
+	Type        string
+	Description string
+}
+
+// eventTracker turns the packets of a flight into its event log: arming and
+// each flight phase as the rocket enters it, and range-safety faults as they
+// start.
+type eventTracker struct {
+	safety models.SafetyConfig
+	prev   *TelemetryPacket
+	faults SafetyFlags
+	failed bool
+}
+
+// Update returns the events the packet brings, oldest first.
+func (e *eventTracker) Update(packet TelemetryPacket) []flightEvent {
+	var vspeed float64
+	if e.prev != nil {
+		vspeed = verticalSpeed(*e.prev, packet)
+	}
+	faults := CheckSafety(packet, vspeed, e.safety)
+	defer func() {
+		e.prev, e.faults, e.failed = &packet, faults, packet.SensorFailed
+	}()
+	if e.prev == nil {
+		return nil
+	}
+
+	var events []flightEvent
+	add := func(typ, format string, args ...any) {
+		events = append(events, flightEvent{
+			Time:        time.UnixMilli(int64(packet.Timestamp)),
+			Type:        typ,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if packet.Armed && !e.prev.Armed {
+		add("arm", "Armed on the pad")
+	}
+	if packet.State != e.prev.State {
+		switch packet.State {
+		case CALIBRATION:
+			add("calibration", "Calibrating sensors")
+		case IGNITION:
+			add("ignition", "Igniter fired")
+		case LAUNCHING:
+			add("launch", "Liftoff")
+		case APEX:
+			add("apex", "Apogee at %.0f m", packet.Altitude)
+		case DESCENDING:
+			add("deploy", "Recovery deployed at %.0f m", packet.Altitude)
+		case LANDED:
+			if e.prev.State == IGNITION {
+				add("abort", "Motor did not light, launch scrubbed")
+			} else if e.prev.State != CALIBRATION {
+				add("land", "Touchdown")
+			}
+		}
+	}
+
+	for _, fault := range []struct {
+		now, before bool
+		description string
+	}{
+		{packet.SensorFailed, e.failed, "Sensor failed"},
+		{faults.GPSLost, e.faults.GPSLost, "GPS fix lost"},
+		{faults.SignalCritical, e.faults.SignalCritical, fmt.Sprintf("Signal below %d dBm", e.safety.MinSignal)},
+		{faults.OverGForce, e.faults.OverGForce, fmt.Sprintf("G-force over %g g", e.safety.MaxGForce)},
+		{faults.CeilingExceeded, e.faults.CeilingExceeded, fmt.Sprintf("Above the %g m ceiling", e.safety.Ceiling)},
+		{faults.DescentWarning, e.faults.DescentWarning, fmt.Sprintf("Descending faster than %g m/s", e.safety.MaxDescentRate)},
+	} {
+		if fault.now && !fault.before {
+			add("fault", "%s", fault.description)
+		}
+	}
+	return events
+}
+
+// eventsFrame is the event log as a frame for logs and table panels: time,
+// type and description per event.
+func eventsFrame(events []flightEvent) *data.Frame {
+	times := make([]time.Time, len(events))
+	types := make([]string, len(events))
+	descriptions := make([]string, len(events))
+	for i, e := range events {
+		times[i], types[i], descriptions[i] = e.Time, e.Type, e.Description
+	}
+	frame := data.NewFrame("events",
+		data.NewField("time", nil, times),
+		data.NewField("type", nil, types),
+		data.NewField("description", nil, descriptions),
+	)
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeLogs}
+	return frame
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestEventTracker(t *testing.T) {
+	gps := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	packets := []TelemetryPacket{
+		{Timestamp: 0, State: LANDED, GPS: gps, Signal: -50},
+		{Timestamp: 1000, State: LANDED, GPS: gps, Signal: -50, Armed: true},
+		{Timestamp: 2000, State: IGNITION, GPS: gps, Signal: -50, Armed: true},
+		{Timestamp: 3000, State: LAUNCHING, GPS: gps, Signal: -50, Altitude: 100},
+		{Timestamp: 4000, State: LAUNCHING, GPSNoFix: true, Signal: -50, Altitude: 200},
+		{Timestamp: 5000, State: APEX, GPSNoFix: true, Signal: -50, Altitude: 250},
+		{Timestamp: 6000, State: DESCENDING, GPS: gps, Signal: -50, Altitude: 240, SensorFailed: true},
+		{Timestamp: 30000, State: LANDED, GPS: gps, Signal: -50, SensorFailed: true},
+	}
+	want := []flightEvent{
+		{Type: "arm", Description: "Armed on the pad"},
+		{Type: "ignition", Description: "Igniter fired"},
+		{Type: "launch", Description: "Liftoff"},
+		{Type: "fault", Description: "GPS fix lost"},
+		{Type: "apex", Description: "Apogee at 250 m"},
+		{Type: "deploy", Description: "Recovery deployed at 240 m"},
+		{Type: "fault", Description: "Sensor failed"},
+		{Type: "land", Description: "Touchdown"},
+	}
+	wantAt := []float64{1000, 2000, 3000, 4000, 5000, 6000, 6000, 30000}
+
+	e := &eventTracker{safety: models.DefaultPluginSettings().Safety}
+	var got []flightEvent
+	for _, p := range packets {
+		got = append(got, e.Update(p)...)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i].Type != want[i].Type || got[i].Description != want[i].Description {
+			t.Errorf("event %d: got %s %q, want %s %q", i, got[i].Type, got[i].Description, want[i].Type, want[i].Description)
+		}
+		if ms := float64(got[i].Time.UnixMilli()); ms != wantAt[i] {
+			t.Errorf("event %d: at %v ms, want %v", i, ms, wantAt[i])
+		}
+	}
+}
+
+func TestEventTrackerScrub(t *testing.T) {
+	e := &eventTracker{safety: models.DefaultPluginSettings().Safety}
+	e.Update(TelemetryPacket{State: IGNITION, Signal: -50, GPS: GPS{Latitude: 1, Longitude: 1}})
+	events := e.Update(TelemetryPacket{Timestamp: 1000, State: LANDED, Signal: -50, GPS: GPS{Latitude: 1, Longitude: 1}})
+	if len(events) != 1 || events[0].Type != "abort" {
+		t.Fatalf("got %+v, want an abort", events)
+	}
+}
+
+func TestEventsFrame(t *testing.T) {
+	frame := eventsFrame(nil)
+	if frame.Rows() != 0 || len(frame.Fields) != 3 {
+		t.Fatalf("empty log: got %d rows and %d fields, want 0 and 3", frame.Rows(), len(frame.Fields))
+	}
+}
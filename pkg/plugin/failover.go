@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const defaultStaleAfter = 2 * time.Second
+
+type prioritizedSource struct {
+	name       string
+	source     Source
+	staleAfter time.Duration
+}
+
+// failoverSource runs several sources at once and forwards packets from the
+// highest priority source that is still delivering fresh data. Forwarded
+// packets carry the name of the active source.
+type failoverSource struct {
+	sources []prioritizedSource
+	now     func() time.Time
+}
+
+type sourcedPacket struct {
+	index  int
+	packet TelemetryPacket
+}
+
+func (f *failoverSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	merged := make(chan sourcedPacket)
+	done := make(chan struct{}, len(f.sources))
+
+	for i, s := range f.sources {
+		packets := make(chan TelemetryPacket)
+		go func() {
+			if err := s.source.Run(ctx, packets); err != nil && ctx.Err() == nil {
+				log.DefaultLogger.Error("Telemetry source stopped", "source", s.name, "error", err)
+			}
+			done <- struct{}{}
+		}()
+		go func() {
+			for {
+				select {
+				case p := <-packets:
+					select {
+					case merged <- sourcedPacket{index: i, packet: p}:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	lastSeen := make([]time.Time, len(f.sources))
+	lastSignal := make([]int, len(f.sources))
+	active := -1
+	running := len(f.sources)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			running--
+			if running == 0 {
+				return nil
+			}
+		case sp := <-merged:
+			now := f.now()
+			lastSeen[sp.index] = now
+			lastSignal[sp.index] = sp.packet.Signal
+
+			if next := f.active(lastSeen, now); next != active {
+				log.DefaultLogger.Info("Switching telemetry source", "source", f.sources[next].name)
+				active = next
+			}
+			if sp.index != active {
+				continue
+			}
+
+			sp.packet.Source = f.sources[active].name
+			sp.packet.Receivers = make(map[string]int, len(f.sources))
+			for i, s := range f.sources {
+				if f.fresh(i, lastSeen, now) {
+					sp.packet.Receivers[s.name] = lastSignal[i]
+				}
+			}
+			select {
+			case out <- sp.packet:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// active returns the highest priority source with fresh data.
+func (f *failoverSource) active(lastSeen []time.Time, now time.Time) int {
+	for i := range f.sources {
+		if f.fresh(i, lastSeen, now) {
+			return i
+		}
+	}
+	return len(f.sources) - 1
+}
+
+func (f *failoverSource) fresh(i int, lastSeen []time.Time, now time.Time) bool {
+	return !lastSeen[i].IsZero() && now.Sub(lastSeen[i]) <= f.sources[i].staleAfter
+}
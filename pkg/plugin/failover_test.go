@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// chanSource forwards packets pushed by a test.
+type chanSource chan TelemetryPacket
+
+func (c chanSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	for {
+		select {
+		case p := <-c:
+			out <- p
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestFailoverSource(t *testing.T) {
+	primary, secondary := make(chanSource), make(chanSource)
+	var now atomic.Int64
+	// The source reads the clock once per packet it merges, so a test that
+	// waits on merged has the packet taken in before sending the next one.
+	merged := make(chan struct{}, 1)
+
+	f := &failoverSource{
+		sources: []prioritizedSource{
+			{name: "serial", source: primary, staleAfter: time.Second},
+			{name: "udp", source: secondary, staleAfter: time.Second},
+		},
+		now: func() time.Time {
+			merged <- struct{}{}
+			return time.UnixMilli(now.Load())
+		},
+	}
+	send := func(src chanSource, p TelemetryPacket) {
+		src <- p
+		<-merged
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan TelemetryPacket)
+	go f.Run(ctx, out)
+
+	send(primary, TelemetryPacket{Altitude: 1})
+	if p := receive(t, out); p.Source != "serial" || p.Altitude != 1 {
+		t.Fatalf("got %+v, want altitude 1 from serial", p)
+	}
+
+	// The secondary is ignored while the primary is fresh, but its signal
+	// is reported.
+	send(secondary, TelemetryPacket{Altitude: 2, Signal: -90})
+	send(primary, TelemetryPacket{Altitude: 3, Signal: -60})
+	if p := receive(t, out); p.Altitude != 3 {
+		t.Fatalf("got altitude %v, want 3 from the primary", p.Altitude)
+	} else if p.Receivers["serial"] != -60 || p.Receivers["udp"] != -90 {
+		t.Fatalf("got receivers %v, want serial -60 and udp -90", p.Receivers)
+	}
+
+	// The primary goes stale, so the secondary takes over.
+	now.Add(2000)
+	send(secondary, TelemetryPacket{Altitude: 4})
+	if p := receive(t, out); p.Source != "udp" || p.Altitude != 4 {
+		t.Fatalf("got %+v, want altitude 4 from udp", p)
+	}
+
+	// And the primary takes back over once it delivers again.
+	send(primary, TelemetryPacket{Altitude: 5})
+	if p := receive(t, out); p.Source != "serial" || p.Altitude != 5 {
+		t.Fatalf("got %+v, want altitude 5 from serial", p)
+	}
+}
@@ -0,0 +1,102 @@
+package plugin
+
+// FieldInfo describes a field that can be requested through Query.Fields.
+type FieldInfo struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"` // "number", "boolean" or "string"
+	// Units lists the units the field can be emitted in, default first.
+	Units []Unit `json:"units,omitempty"`
+}
+
+// fieldRegistry is the central list of fields the datasource can emit, in
+// frame order. It backs the /fields and /schema endpoints and unit handling in
+// the frame builder.
+var fieldRegistry = []FieldInfo{
+	{Name: "altitude", Label: "Altitude", Type: "number", Units: altitudeUnits},
+	{Name: "rawAltitude", Label: "Raw Altitude", Type: "number", Units: altitudeUnits},
+	{Name: "filteredAltitude", Label: "Filtered Altitude", Type: "number", Units: altitudeUnits},
+	{Name: "filteredAltitudeVariance", Label: "Filtered Altitude Variance", Type: "number", Units: varianceUnits},
+	{Name: "altitudeAscent", Label: "Altitude (Ascent)", Type: "number", Units: altitudeUnits},
+	{Name: "altitudeDescent", Label: "Altitude (Descent)", Type: "number", Units: altitudeUnits},
+	{Name: "latitude", Label: "Latitude", Type: "number", Units: degreeUnits},
+	{Name: "longitude", Label: "Longitude", Type: "number", Units: degreeUnits},
+	{Name: "east", Label: "East", Type: "number", Units: lengthUnits},
+	{Name: "north", Label: "North", Type: "number", Units: lengthUnits},
+	{Name: "up", Label: "Up", Type: "number", Units: lengthUnits},
+	{Name: "enu", Label: "ENU Position", Type: "string"},
+	{Name: "downrange", Label: "Downrange", Type: "number", Units: lengthUnits},
+	{Name: "crossrange", Label: "Crossrange", Type: "number", Units: lengthUnits},
+	{Name: "distance", Label: "Distance", Type: "number", Units: lengthUnits},
+	{Name: "pathDistance", Label: "Path Distance", Type: "number", Units: lengthUnits},
+	{Name: "gpsFix", Label: "GPS Fix", Type: "boolean"},
+	{Name: "deadReckoned", Label: "Dead Reckoned", Type: "boolean"},
+	{Name: "heading", Label: "Heading", Type: "number", Units: degreeUnits},
+	{Name: "state", Label: "State", Type: "number"},
+	{Name: "detectedState", Label: "Detected State", Type: "number"},
+	{Name: "pitch", Label: "Pitch", Type: "number", Units: degreeUnits},
+	{Name: "roll", Label: "Roll", Type: "number", Units: degreeUnits},
+	{Name: "yaw", Label: "Yaw", Type: "number", Units: degreeUnits},
+	{Name: "tiltAngle", Label: "Tilt From Vertical", Type: "number", Units: degreeUnits},
+	{Name: "stabilityIndex", Label: "Stability Index", Type: "number", Units: ratioUnits},
+	{Name: "pitchRate", Label: "Pitch Rate", Type: "number", Units: degreeRateUnits},
+	{Name: "rollRate", Label: "Roll Rate", Type: "number", Units: degreeRateUnits},
+	{Name: "yawRate", Label: "Yaw Rate", Type: "number", Units: degreeRateUnits},
+	{Name: "attitude", Label: "Attitude", Type: "string"},
+	{Name: "gforce", Label: "G-Force", Type: "number"},
+	{Name: "gforceAvg", Label: "G-Force (Rolling Average)", Type: "number"},
+	{Name: "gforceClamped", Label: "G-Force Clamped", Type: "boolean"},
+	{Name: "signal", Label: "Signal", Type: "number", Units: dbmUnits},
+	{Name: "signalPercent", Label: "Signal %", Type: "number", Units: percentUnits},
+	{Name: "packetLossPercent", Label: "Packet Loss %", Type: "number", Units: percentUnits},
+	{Name: "mach", Label: "Mach", Type: "number"},
+	{Name: "railExitVelocity", Label: "Rail Exit Velocity", Type: "number", Units: velocityUnits},
+	{Name: "descentRateFpm", Label: "Descent Rate", Type: "number", Units: descentRateUnits},
+	{Name: "landingETA", Label: "Landing ETA", Type: "number", Units: secondUnits},
+	{Name: "predictedLandingLat", Label: "Predicted Landing Latitude", Type: "number", Units: degreeUnits},
+	{Name: "predictedLandingLon", Label: "Predicted Landing Longitude", Type: "number", Units: degreeUnits},
+	{Name: "flightProgress", Label: "Flight Progress", Type: "number", Units: ratioUnits},
+	{Name: "apogeeEstimate", Label: "Apogee Estimate", Type: "number", Units: altitudeUnits},
+	{Name: "timeInBoost", Label: "Time In Boost", Type: "number", Units: secondUnits},
+	{Name: "timeInCoast", Label: "Time In Coast", Type: "number", Units: secondUnits},
+	{Name: "timeInDescent", Label: "Time In Descent", Type: "number", Units: secondUnits},
+	{Name: "dataConsistency", Label: "Data Consistency", Type: "number", Units: ratioUnits},
+	{Name: "dataInconsistent", Label: "Data Inconsistent", Type: "boolean"},
+	{Name: "sensorFailed", Label: "Sensor Failed", Type: "boolean"},
+	{Name: "altitudeSaturated", Label: "Altitude Saturated", Type: "boolean"},
+	{Name: "source", Label: "Source", Type: "string"},
+	{Name: "overGForce", Label: "Over G-Force", Type: "boolean"},
+	{Name: "descentWarning", Label: "Descent Warning", Type: "boolean"},
+	{Name: "ceilingExceeded", Label: "Ceiling Exceeded", Type: "boolean"},
+	{Name: "signalCritical", Label: "Signal Critical", Type: "boolean"},
+	{Name: "gpsLost", Label: "GPS Lost", Type: "boolean"},
+	{Name: "outsideGeofence", Label: "Outside Geofence", Type: "boolean"},
+}
+
+func lookupField(name string) (FieldInfo, bool) {
+	for _, f := range fieldRegistry {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// fieldSchema is the per-field unit metadata served by /schema.
+type fieldSchema struct {
+	Type        string `json:"type"`
+	Units       []Unit `json:"units,omitempty"`
+	DefaultUnit string `json:"defaultUnit,omitempty"`
+}
+
+func registrySchema() map[string]fieldSchema {
+	schema := make(map[string]fieldSchema, len(fieldRegistry))
+	for _, f := range fieldRegistry {
+		s := fieldSchema{Type: f.Type, Units: f.Units}
+		if len(f.Units) > 0 {
+			s.DefaultUnit = f.Units[0].ID
+		}
+		schema[f.Name] = s
+	}
+	return schema
+}
@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestRegistryMatchesFrame(t *testing.T) {
+	frame := newFrameBuilder(defaultQuery(), models.DefaultPluginSettings()).Build(TelemetryPacket{})
+
+	names := map[string]bool{}
+	for _, f := range frame.Fields {
+		names[f.Name] = true
+	}
+	for _, info := range fieldRegistry {
+		if !names[info.Name] {
+			t.Errorf("registry field %q is not emitted", info.Name)
+		}
+		delete(names, info.Name)
+	}
+	delete(names, "time")
+	for name := range names {
+		t.Errorf("emitted field %q is missing from the registry", name)
+	}
+}
+
+func TestUnitConversion(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"altitude"}
+	q.Units = map[string]string{"altitude": "ft"}
+
+	frame := newFrameBuilder(q, models.DefaultPluginSettings()).Build(TelemetryPacket{Altitude: 100})
+	field, _ := frame.FieldByName("altitude")
+
+	if got := field.At(0).(float64); math.Abs(got-328.084) > 1e-6 {
+		t.Errorf("got %v ft, want 328.084", got)
+	}
+	if field.Config == nil || field.Config.Unit != "lengthft" {
+		t.Errorf("got field config %+v, want unit lengthft", field.Config)
+	}
+}
+
+func TestFlightLevelUnit(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"altitude", "latitude"}
+	q.Units = map[string]string{"altitude": "FL", "latitude": "FL"}
+
+	frame := newFrameBuilder(q, models.DefaultPluginSettings()).Build(TelemetryPacket{Altitude: 10668, GPS: GPS{Latitude: 37}})
+	altitude, _ := frame.FieldByName("altitude")
+	if got := altitude.At(0).(float64); math.Abs(got-350) > 0.01 {
+		t.Errorf("got FL%v, want FL350", got)
+	}
+	if altitude.Config.Unit != "prefix:FL" {
+		t.Errorf("got unit %q, want prefix:FL", altitude.Config.Unit)
+	}
+
+	// Flight levels only apply to altitudes.
+	latitude, _ := frame.FieldByName("latitude")
+	if got := latitude.At(0).(float64); got != 37 {
+		t.Errorf("latitude converted to %v", got)
+	}
+}
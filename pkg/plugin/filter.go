@@ -0,0 +1,107 @@
+package plugin
+
+import "github.com/grafana/grafana-plugin-sdk-go/backend/log"
+
+const (
+	FilterEMA    = "ema"
+	FilterKalman = "kalman"
+)
+
+// FilterConfig selects the filter behind filteredAltitude.
+type FilterConfig struct {
+	Type string `json:"type"` // FilterEMA (default) or FilterKalman
+	// Alpha is the EMA weight of each new sample, between 0 and 1.
+	Alpha float64 `json:"alpha"`
+	// ProcessNoise and MeasurementNoise tune the Kalman filter: how much the
+	// vertical acceleration may wander (m/s²) and how noisy the altimeter is
+	// (m), both as standard deviations.
+	ProcessNoise     float64 `json:"processNoise"`
+	MeasurementNoise float64 `json:"measurementNoise"`
+}
+
+// altitudeFilter smooths a stream of altitude samples. t is in seconds.
+type altitudeFilter interface {
+	Update(t, altitude float64) float64
+}
+
+// varianceFilter is implemented by filters that estimate the variance of
+// their output, in m².
+type varianceFilter interface {
+	Variance() float64
+}
+
+func newAltitudeFilter(cfg FilterConfig) altitudeFilter {
+	switch cfg.Type {
+	case FilterKalman:
+		return &kalmanFilter{q: cfg.ProcessNoise * cfg.ProcessNoise, r: cfg.MeasurementNoise * cfg.MeasurementNoise}
+	case "", FilterEMA:
+	default:
+		log.DefaultLogger.Warn("Unknown altitude filter, using EMA", "filter", cfg.Type)
+	}
+	return &emaFilter{alpha: cfg.Alpha}
+}
+
+// emaFilter is an exponential moving average.
+type emaFilter struct {
+	alpha   float64
+	value   float64
+	started bool
+}
+
+func (f *emaFilter) Update(_, altitude float64) float64 {
+	if !f.started {
+		f.value, f.started = altitude, true
+		return f.value
+	}
+	f.value += f.alpha * (altitude - f.value)
+	return f.value
+}
+
+// kalmanFilter tracks altitude and vertical speed with a constant velocity
+// model, so unlike the EMA it does not lag behind a steady climb.
+type kalmanFilter struct {
+	q, r    float64
+	t       float64
+	h, v    float64       // state: altitude, vertical speed
+	p       [2][2]float64 // state covariance
+	started bool
+}
+
+func (f *kalmanFilter) Update(t, altitude float64) float64 {
+	if !f.started {
+		f.t, f.h, f.started = t, altitude, true
+		f.p = [2][2]float64{{f.r, 0}, {0, 1e4}}
+		return f.h
+	}
+
+	dt := t - f.t
+	f.t = t
+	if dt > 0 {
+		// Predict.
+		f.h += f.v * dt
+		p := f.p
+		f.p[0][0] = p[0][0] + dt*(p[0][1]+p[1][0]) + dt*dt*p[1][1] + f.q*dt*dt*dt*dt/4
+		f.p[0][1] = p[0][1] + dt*p[1][1] + f.q*dt*dt*dt/2
+		f.p[1][0] = p[1][0] + dt*p[1][1] + f.q*dt*dt*dt/2
+		f.p[1][1] = p[1][1] + f.q*dt*dt
+	}
+
+	// Correct with the measured altitude.
+	s := f.p[0][0] + f.r
+	k0, k1 := f.p[0][0]/s, f.p[1][0]/s
+	residual := altitude - f.h
+	f.h += k0 * residual
+	f.v += k1 * residual
+	p := f.p
+	f.p[0][0] = (1 - k0) * p[0][0]
+	f.p[0][1] = (1 - k0) * p[0][1]
+	f.p[1][0] = p[1][0] - k1*p[0][0]
+	f.p[1][1] = p[1][1] - k1*p[0][1]
+
+	return f.h
+}
+
+// Variance is the filter's altitude variance after the last update.
+func (f *kalmanFilter) Variance() float64 {
+	return f.p[0][0]
+}
@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestKalmanTracksClimbWithoutLag(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ema := newAltitudeFilter(FilterConfig{Type: FilterEMA, Alpha: 0.3})
+	kalman := newAltitudeFilter(FilterConfig{Type: FilterKalman, ProcessNoise: 2, MeasurementNoise: 3})
+
+	var emaErr, kalmanErr float64
+	for i := 0; i <= 100; i++ {
+		ts := float64(i) * 0.5
+		truth := 50 * ts
+		z := truth + rng.NormFloat64()*3
+		e, k := ema.Update(ts, z), kalman.Update(ts, z)
+		if i >= 50 {
+			emaErr += math.Abs(e - truth)
+			kalmanErr += math.Abs(k - truth)
+		}
+	}
+	if kalmanErr >= emaErr {
+		t.Errorf("kalman error %v not below ema error %v on a steady climb", kalmanErr, emaErr)
+	}
+	if kalmanErr/50 > 3 {
+		t.Errorf("kalman mean error %v m, want under the 3 m noise", kalmanErr/50)
+	}
+}
+
+func TestRawAndFilteredAltitudeSideBySide(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"altitude", "filteredAltitude"}
+	b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+	b.Build(TelemetryPacket{Timestamp: 0, Altitude: 100})
+	frame := b.Build(TelemetryPacket{Timestamp: 500, Altitude: 200})
+
+	raw, _ := frame.FieldByName("altitude")
+	filtered, _ := frame.FieldByName("filteredAltitude")
+	if raw == nil || filtered == nil {
+		t.Fatalf("want both fields, got %d fields", len(frame.Fields))
+	}
+	if got := raw.At(0).(float64); got != 200 {
+		t.Errorf("altitude = %v, want raw 200", got)
+	}
+	if got := filtered.At(0).(float64); got != 130 {
+		t.Errorf("filteredAltitude = %v, want EMA 130", got)
+	}
+}
+
+func TestKalmanVarianceConverges(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"filteredAltitudeVariance"}
+	q.Filter.Type = FilterKalman
+	b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+	var variances []float64
+	for i := 0; i < 40; i++ {
+		frame := b.Build(TelemetryPacket{Timestamp: float64(i) * 500, Altitude: float64(i) * 25})
+		v, ok := frame.Fields[1].ConcreteAt(0)
+		if !ok {
+			t.Fatalf("packet %d: no variance from the kalman filter", i)
+		}
+		variances = append(variances, v.(float64))
+	}
+	r := q.Filter.MeasurementNoise * q.Filter.MeasurementNoise
+	if variances[0] != r {
+		t.Errorf("initial variance %v, want the measurement variance %v", variances[0], r)
+	}
+	if last := variances[len(variances)-1]; last >= r || last <= 0 {
+		t.Errorf("variance settled at %v, want between 0 and %v", last, r)
+	}
+
+	q.Filter.Type = FilterEMA
+	frame := newFrameBuilder(q, models.DefaultPluginSettings()).Build(TelemetryPacket{Altitude: 10})
+	if _, ok := frame.Fields[1].ConcreteAt(0); ok {
+		t.Error("the EMA reports a variance")
+	}
+}
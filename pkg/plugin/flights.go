@@ -0,0 +1,314 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const standardGravity = 9.80665 // m/s²
+
+// flightStore reads recorded flights from a directory of capture files.
+type flightStore struct {
+	dir    string
+	parser *PacketParser
+}
+
+// Flight is one recorded flight.
+type Flight struct {
+	ID      string
+	Packets []TelemetryPacket
+}
+
+// Flights reads every flight in the store, ordered by ID.
+func (s *flightStore) Flights() ([]Flight, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var flights []Flight
+	for _, e := range entries {
+		if !isFlightFile(e) {
+			continue
+		}
+		f, err := s.read(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		flights = append(flights, f)
+	}
+	sort.Slice(flights, func(i, j int) bool { return flights[i].ID < flights[j].ID })
+	return flights, nil
+}
+
+// Flight reads the flight with the given ID. The ID is matched against the
+// store's files rather than joined onto its path, so it cannot escape it.
+func (s *flightStore) Flight(id string) (Flight, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return Flight{}, err
+	}
+	for _, e := range entries {
+		if isFlightFile(e) && flightID(e.Name()) == id {
+			return s.read(e.Name())
+		}
+	}
+	return Flight{}, fmt.Errorf("flight %q: %w", id, os.ErrNotExist)
+}
+
+func (s *flightStore) read(name string) (Flight, error) {
+	packets, err := readPackets(filepath.Join(s.dir, name), s.parser, nil)
+	return Flight{ID: flightID(name), Packets: packets}, err
+}
+
+func isFlightFile(e os.DirEntry) bool {
+	return e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".")
+}
+
+// flightID is a flight's file name without its extension.
+func flightID(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// FlightSummary holds the key figures of one flight.
+type FlightSummary struct {
+	ID          string  `json:"id"`
+	Apogee      float64 `json:"apogee"`      // m
+	MaxVelocity float64 `json:"maxVelocity"` // peak climb rate, m/s
+	// CoastEfficiency is the altitude gained between burnout and apogee
+	// relative to the drag-free gain v²/2g for the burnout velocity. 1 is a
+	// coast without drag.
+	CoastEfficiency float64 `json:"coastEfficiency"`
+	// PhaseTimes are those of the last flight in the packets.
+	PhaseTimes
+	// RailExitVelocity is the climb rate in m/s as the rocket cleared the
+	// rail, 0 when it never did.
+	RailExitVelocity float64 `json:"railExitVelocity"`
+	// Seed recreates a simulated flight through the simulation seed setting.
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// SummarizeFlight derives the apogee, peak climb rate, coast efficiency,
+// phase times and rail exit velocity, for a rail of railLength m, of a
+// flight. Burnout is taken where the climb rate peaks.
+func SummarizeFlight(f Flight, railLength float64) FlightSummary {
+	summary := FlightSummary{ID: f.ID}
+	if len(f.Packets) > 0 {
+		summary.Seed = f.Packets[0].Seed
+	}
+	var burnoutAltitude float64
+	var phases phaseTimer
+	rail := railExit{length: railLength}
+	for i, p := range f.Packets {
+		summary.Apogee = max(summary.Apogee, p.Altitude)
+		var v float64
+		if i > 0 {
+			if v = verticalSpeed(f.Packets[i-1], p); v > summary.MaxVelocity {
+				summary.MaxVelocity = v
+				burnoutAltitude = p.Altitude
+			}
+		}
+		summary.PhaseTimes = phases.Update(p, v)
+		if exit, ok := rail.Update(p, v); ok {
+			summary.RailExitVelocity = exit
+		}
+	}
+	if ideal := summary.MaxVelocity * summary.MaxVelocity / (2 * standardGravity); ideal > 0 {
+		summary.CoastEfficiency = (summary.Apogee - burnoutAltitude) / ideal
+	}
+	return summary
+}
+
+// summaryFrame is a single row frame of a flight summary.
+func summaryFrame(s FlightSummary) *data.Frame {
+	apogee := data.NewField("apogee", nil, []float64{s.Apogee})
+	apogee.Config = &data.FieldConfig{Unit: "lengthm"}
+	velocity := data.NewField("maxVelocity", nil, []float64{s.MaxVelocity})
+	velocity.Config = &data.FieldConfig{Unit: "velocityms"}
+	efficiency := data.NewField("coastEfficiency", nil, []float64{s.CoastEfficiency})
+	efficiency.Config = &data.FieldConfig{Unit: "percentunit"}
+	railExit := data.NewField("railExitVelocity", nil, []float64{s.RailExitVelocity})
+	railExit.Config = &data.FieldConfig{Unit: "velocityms"}
+	frame := data.NewFrame("summary", apogee, velocity, efficiency, railExit)
+	for _, phase := range []struct {
+		name string
+		v    float64
+	}{{"timeInBoost", s.Boost}, {"timeInCoast", s.Coast}, {"timeInDescent", s.Descent}} {
+		field := data.NewField(phase.name, nil, []float64{phase.v})
+		field.Config = &data.FieldConfig{Unit: "s"}
+		frame.Fields = append(frame.Fields, field)
+	}
+	if s.Seed != 0 {
+		frame.Meta = &data.FrameMeta{Custom: map[string]any{"seed": s.Seed}}
+	}
+	return frame
+}
+
+// FlightRecord is a leaderboard entry: the best value and the flight that set it.
+type FlightRecord struct {
+	Flight string  `json:"flight"`
+	Value  float64 `json:"value"`
+}
+
+// FlightStats aggregates every flight in the store.
+type FlightStats struct {
+	TotalFlights    int           `json:"totalFlights"`
+	AverageApogee   float64       `json:"averageApogee"`
+	HighestApogee   *FlightRecord `json:"highestApogee"`
+	FastestVelocity *FlightRecord `json:"fastestVelocity"`
+}
+
+// AggregateFlights combines flight summaries into store-wide statistics.
+// Records are nil when there are no flights.
+func AggregateFlights(summaries []FlightSummary) FlightStats {
+	stats := FlightStats{TotalFlights: len(summaries)}
+	if len(summaries) == 0 {
+		return stats
+	}
+
+	var total float64
+	for _, s := range summaries {
+		total += s.Apogee
+		if stats.HighestApogee == nil || s.Apogee > stats.HighestApogee.Value {
+			stats.HighestApogee = &FlightRecord{Flight: s.ID, Value: s.Apogee}
+		}
+		if stats.FastestVelocity == nil || s.MaxVelocity > stats.FastestVelocity.Value {
+			stats.FastestVelocity = &FlightRecord{Flight: s.ID, Value: s.MaxVelocity}
+		}
+	}
+	stats.AverageApogee = total / float64(len(summaries))
+	return stats
+}
+
+// handleFlightStats returns aggregates across all stored flights.
+func (d *Datasource) handleFlightStats(w http.ResponseWriter, _ *http.Request) {
+	if d.settings.FlightDir == "" {
+		http.Error(w, "no flight store configured", http.StatusNotFound)
+		return
+	}
+	parser, err := NewPacketParser(d.settings.Parser)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	flights, err := (&flightStore{dir: d.settings.FlightDir, parser: parser}).Flights()
+	if err != nil {
+		http.Error(w, "read flight store: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	summaries := make([]FlightSummary, len(flights))
+	for i, f := range flights {
+		summaries[i] = SummarizeFlight(f, d.settings.RailLength)
+	}
+	writeJSON(w, AggregateFlights(summaries))
+}
+
+// handleFlightExport serves GET /flight/{id}.gpx and /flight/{id}.csv, a
+// stored flight exported for other tools:
+//
+//	.gpx                 the GPS track, for mapping and recovery apps
+//	.csv                 every field, or those in ?fields=a,b, with a header row
+//	.csv?format=radio    radio packet lines that ParsePacket reads back, without
+//	                     a header
+//
+// ?header=true or ?header=false overrides the CSV header default.
+func (d *Datasource) handleFlightExport(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	ext := filepath.Ext(file)
+	if ext != ".gpx" && ext != ".csv" {
+		http.NotFound(w, r)
+		return
+	}
+	id := strings.TrimSuffix(file, ext)
+	var export csvExport
+	if ext == ".csv" {
+		var err error
+		if export, err = parseCSVExport(r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if d.settings.FlightDir == "" {
+		http.Error(w, "no flight store configured", http.StatusNotFound)
+		return
+	}
+	parser, err := NewPacketParser(d.settings.Parser)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	flight, err := (&flightStore{dir: d.settings.FlightDir, parser: parser}).Flight(id)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "read flight: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var doc []byte
+	contentType := "application/gpx+xml"
+	if ext == ".csv" {
+		contentType = "text/csv"
+		doc, err = d.flightCSV(flight, export)
+	} else {
+		doc, err = MarshalGPX(flight)
+	}
+	if err != nil {
+		http.Error(w, "export flight: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file))
+	_, _ = w.Write(doc)
+}
+
+// csvExport holds the CSV export's options.
+type csvExport struct {
+	radio, header bool
+	q             Query
+}
+
+// parseCSVExport reads the CSV export's query parameters.
+func parseCSVExport(params url.Values) (csvExport, error) {
+	e := csvExport{radio: params.Get("format") == "radio"}
+	switch format := params.Get("format"); format {
+	case "", "fields", "radio":
+	default:
+		return e, fmt.Errorf("unknown format %q", format)
+	}
+	e.header = !e.radio
+	if h := params.Get("header"); h != "" {
+		var err error
+		if e.header, err = strconv.ParseBool(h); err != nil {
+			return e, fmt.Errorf("invalid header %q", h)
+		}
+	}
+
+	q := defaultQuery()
+	if fields := params.Get("fields"); fields != "" {
+		q.Fields = strings.Split(fields, ",")
+	}
+	var err error
+	e.q, err = q.Normalize()
+	return e, err
+}
+
+// flightCSV encodes a flight per the CSV export's options.
+func (d *Datasource) flightCSV(f Flight, e csvExport) ([]byte, error) {
+	if e.radio {
+		return MarshalRadioCSV(f.Packets, e.header), nil
+	}
+	return MarshalFrameCSV(newFrameBuilder(e.q, d.settings).BuildAll(f.Packets), e.header)
+}
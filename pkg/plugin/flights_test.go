@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFlight stores a flight sampled every second at the given altitudes.
+func writeFlight(t *testing.T, dir, name string, altitudes ...float64) {
+	t.Helper()
+	var b strings.Builder
+	for i, alt := range altitudes {
+		fmt.Fprintf(&b, "%d,90,0,0,1.0,%g,37.7749,-122.4194,LAUNCHING,10\n", i*1000, alt)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlightStoreAggregates(t *testing.T) {
+	dir := t.TempDir()
+	writeFlight(t, dir, "alpha.log", 0, 100, 250, 300, 200, 0)
+	writeFlight(t, dir, "bravo.log", 0, 180, 330, 420, 100, 0)
+	writeFlight(t, dir, "charlie.log", 0, 50, 90, 0)
+
+	flights, err := (&flightStore{dir: dir, parser: defaultParser}).Flights()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summaries []FlightSummary
+	for _, f := range flights {
+		summaries = append(summaries, SummarizeFlight(f, 0))
+	}
+	stats := AggregateFlights(summaries)
+
+	if stats.TotalFlights != 3 {
+		t.Errorf("total flights = %d, want 3", stats.TotalFlights)
+	}
+	if *stats.HighestApogee != (FlightRecord{Flight: "bravo", Value: 420}) {
+		t.Errorf("highest apogee = %+v, want bravo at 420", *stats.HighestApogee)
+	}
+	if *stats.FastestVelocity != (FlightRecord{Flight: "bravo", Value: 180}) {
+		t.Errorf("fastest = %+v, want bravo at 180 m/s", *stats.FastestVelocity)
+	}
+	if stats.AverageApogee != 270 {
+		t.Errorf("average apogee = %v, want 270", stats.AverageApogee)
+	}
+}
+
+func TestAggregateNoFlights(t *testing.T) {
+	stats := AggregateFlights(nil)
+	if stats.TotalFlights != 0 || stats.HighestApogee != nil {
+		t.Errorf("got %+v for an empty store", stats)
+	}
+}
+
+func TestCoastEfficiency(t *testing.T) {
+	// Burnout at 100 m climbing 100 m/s; drag-free the rocket would coast
+	// another 510 m.
+	ideal := 100 * 100 / (2 * standardGravity)
+	f := Flight{Packets: []TelemetryPacket{
+		{Timestamp: 0, Altitude: 0},
+		{Timestamp: 1000, Altitude: 100},
+		{Timestamp: 2000, Altitude: 180},
+		{Timestamp: 8000, Altitude: 100 + ideal*0.8},
+		{Timestamp: 9000, Altitude: 300},
+	}}
+
+	s := SummarizeFlight(f, 0)
+	if math.Abs(s.CoastEfficiency-0.8) > 1e-9 {
+		t.Errorf("coast efficiency = %v, want 0.8", s.CoastEfficiency)
+	}
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// formationMember returns the simulation config of the i-th rocket of the
+// configured formation. Without a formation only rocket 0 exists and flies
+// the config as is.
+func formationMember(cfg models.SimulationConfig, i int) (models.SimulationConfig, error) {
+	f := cfg.Formation
+	count := max(f.Count, 1)
+	if i < 0 || i >= count {
+		return cfg, fmt.Errorf("rocket %d is not in a formation of %d", i, count)
+	}
+	if count == 1 {
+		return cfg, nil
+	}
+
+	// Line the pads up across the launch azimuth, centred on the configured
+	// pad, so the rockets fly parallel rather than into each other.
+	offset := (float64(i) - float64(count-1)/2) * f.Spacing
+	across := toRadians(cfg.LaunchAzimuth + 90)
+	cfg.PadNorth += offset * math.Cos(across)
+	cfg.PadEast += offset * math.Sin(across)
+	cfg.LaunchDelay += float64(i) * f.Stagger
+
+	if f.Perturbation > 0 {
+		// Perturb by rocket rather than by flight, so each member keeps its
+		// own distinct trajectory from one stream to the next.
+		rng := rand.New(rand.NewSource(cfg.Seed + int64(i)))
+		cfg.LaunchAngle = math.Abs(cfg.LaunchAngle + f.Perturbation*(2*rng.Float64()-1))
+		cfg.LaunchAzimuth = wrapDegrees(cfg.LaunchAzimuth + f.Perturbation*(2*rng.Float64()-1))
+	}
+	// Rockets sharing one seed would share their random events too.
+	if cfg.Seed != 0 {
+		cfg.Seed += int64(i)
+	}
+	return cfg, nil
+}
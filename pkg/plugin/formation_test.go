@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestFormationMember(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.Seed = 7
+	cfg.Formation = models.FormationConfig{Count: 3, Spacing: 100, Stagger: 2, Perturbation: 3}
+
+	var pads []GPS
+	for i := range 3 {
+		member, err := formationMember(cfg, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := cfg.LaunchDelay + 2*float64(i); member.LaunchDelay != want {
+			t.Errorf("rocket %d: launch delay = %v, want %v", i, member.LaunchDelay, want)
+		}
+		if member.LaunchAngle == cfg.LaunchAngle && member.LaunchAzimuth == cfg.LaunchAzimuth {
+			t.Errorf("rocket %d: trajectory not perturbed", i)
+		}
+		if d := member.LaunchAngle - cfg.LaunchAngle; d > 3 || d < -3 {
+			t.Errorf("rocket %d: launch angle perturbed by %v, want at most 3", i, d)
+		}
+		if member.Seed != cfg.Seed+int64(i) {
+			t.Errorf("rocket %d: seed = %d, want %d", i, member.Seed, cfg.Seed+int64(i))
+		}
+		packet := NewRocketSimulationAt(time.UnixMilli(0), member).TickAt(time.UnixMilli(0))
+		pads = append(pads, packet.GPS)
+	}
+
+	for i := 1; i < len(pads); i++ {
+		d := Haversine(pads[i-1].Latitude, pads[i-1].Longitude, pads[i].Latitude, pads[i].Longitude)
+		if d < 99 || d > 101 {
+			t.Errorf("pads %d and %d are %.1f m apart, want 100", i-1, i, d)
+		}
+	}
+	// The formation is centred on the configured pad.
+	site := NewRocketSimulationAt(time.UnixMilli(0), cfg).TickAt(time.UnixMilli(0)).GPS
+	if d := Haversine(site.Latitude, site.Longitude, pads[1].Latitude, pads[1].Longitude); d > 0.1 {
+		t.Errorf("middle pad is %.1f m from the launch site, want 0", d)
+	}
+}
+
+func TestFormationMemberOutOfRange(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	if _, err := formationMember(cfg, 1); err == nil {
+		t.Error("rocket 1 accepted without a formation")
+	}
+	cfg.Formation.Count = 2
+	if _, err := formationMember(cfg, 1); err != nil {
+		t.Errorf("rocket 1 of 2: %v", err)
+	}
+	if _, err := formationMember(cfg, 2); err == nil {
+		t.Error("rocket 2 accepted in a formation of 2")
+	}
+}
@@ -0,0 +1,466 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// frameBuilder turns telemetry packets into the frames sent on a stream. It
+// keeps whatever state is needed to derive fields across consecutive packets.
+type frameBuilder struct {
+	q         Query
+	settings  models.PluginSettings
+	prev      *TelemetryPacket
+	zero      *launchZero
+	course    courseTracker
+	track     groundTrack
+	filter    altitudeFilter
+	held      map[string]float64 // last value emitted per deadbanded field
+	sequence  *stateSequencer
+	loss      *lossTracker
+	origin    launchOrigin
+	ranges    rangeDecomposer
+	path      pathTracker
+	progress  progressTracker
+	apogee    apogeeEstimator
+	phases    phaseTimer
+	rail      railExit
+	gforce    *rollingWindow
+	stable    *stabilityTracker
+	saturated saturationDetector
+	receivers []string // names of the sources, with q.SignalPerReceiver
+}
+
+func newFrameBuilder(q Query, settings models.PluginSettings) *frameBuilder {
+	b := &frameBuilder{
+		q:        q,
+		settings: settings,
+		filter:   newAltitudeFilter(q.Filter),
+		loss:     newLossTracker(q.LossWindow, settings.Signal.ExpectedInterval, settings.Simulation.Cadence),
+		gforce:   newRollingWindow(q.GForceWindow),
+		stable:   newStabilityTracker(q.StabilityWindow),
+	}
+	b.saturated.cfg = settings.Saturation
+	b.ranges.azimuth = q.LaunchAzimuth
+	b.rail.length = settings.RailLength
+	b.apogee.alpha = q.ApogeeSmoothing
+	if q.SmoothState {
+		b.sequence = &stateSequencer{resetAfter: q.StateResetAfter}
+	}
+	if settings.ZeroAltitude {
+		b.zero = &launchZero{}
+	}
+	if q.SignalPerReceiver && len(settings.Sources) > 1 {
+		for i, cfg := range settings.Sources {
+			b.receivers = append(b.receivers, sourceName(cfg, i))
+		}
+	}
+	return b
+}
+
+func (b *frameBuilder) Build(packet TelemetryPacket) *data.Frame {
+	q := b.q
+	// The altimeter saturates on its own reading, before any zeroing.
+	saturated := b.saturated.Update(packet)
+	if b.zero != nil {
+		packet = b.zero.Apply(packet)
+	}
+	rawAltitude := packet.Altitude
+	if b.settings.ClampAltitude && packet.Altitude < 0 {
+		packet.Altitude = 0
+	}
+
+	frame := data.NewFrame("response")
+	if packet.Seed != 0 {
+		frame.Meta = &data.FrameMeta{Custom: map[string]any{"seed": packet.Seed}}
+	}
+
+	var vspeed float64
+	if b.prev != nil {
+		vspeed = verticalSpeed(*b.prev, packet)
+	}
+
+	// Always include time
+	frame.Fields = append(frame.Fields, data.NewField("time", nil, []time.Time{time.UnixMilli(int64(packet.Timestamp))}))
+
+	if q.shouldInclude("altitude") {
+		frame.Fields = append(frame.Fields, b.number("altitude", packet.Altitude))
+	}
+	if q.shouldInclude("rawAltitude") {
+		frame.Fields = append(frame.Fields, b.number("rawAltitude", rawAltitude))
+	}
+	if q.shouldInclude("filteredAltitude") || q.shouldInclude("filteredAltitudeVariance") {
+		filtered := b.filter.Update(packet.Timestamp/1000, packet.Altitude)
+		if q.shouldInclude("filteredAltitude") {
+			frame.Fields = append(frame.Fields, b.number("filteredAltitude", filtered))
+		}
+		// Only the Kalman filter has a variance, the EMA leaves it null.
+		if q.shouldInclude("filteredAltitudeVariance") {
+			vf, ok := b.filter.(varianceFilter)
+			var variance float64
+			if ok {
+				variance = vf.Variance()
+			}
+			frame.Fields = append(frame.Fields, b.nullableNumber("filteredAltitudeVariance", variance, ok))
+		}
+	}
+	if q.shouldInclude("altitudeAscent") || q.shouldInclude("altitudeDescent") {
+		ascending, descending := flightLeg(packet, vspeed, q.PhaseBy)
+		if q.shouldInclude("altitudeAscent") {
+			frame.Fields = append(frame.Fields, b.nullableNumber("altitudeAscent", packet.Altitude, ascending))
+		}
+		if q.shouldInclude("altitudeDescent") {
+			frame.Fields = append(frame.Fields, b.nullableNumber("altitudeDescent", packet.Altitude, descending))
+		}
+	}
+	outside := outsideGeofence(packet.GPS, b.settings.Geofence)
+	if q.shouldInclude("latitude") {
+		frame.Fields = append(frame.Fields, b.position("latitude", packet.GPS.Latitude, outside))
+	}
+	if q.shouldInclude("longitude") {
+		frame.Fields = append(frame.Fields, b.position("longitude", packet.GPS.Longitude, outside))
+	}
+
+	// Position in meters east, north and up of the launch point, for 3D scene
+	// panels: as three fields or one JSON object. Offsets from the pad give
+	// the position away as much as coordinates do, so the fence hides them
+	// too.
+	east, north, up := b.origin.Update(packet)
+	for _, axis := range []struct {
+		name string
+		v    float64
+	}{{"east", east}, {"north", north}} {
+		if q.shouldInclude(axis.name) {
+			frame.Fields = append(frame.Fields, b.position(axis.name, axis.v, outside))
+		}
+	}
+	if q.shouldInclude("up") {
+		frame.Fields = append(frame.Fields, b.number("up", up))
+	}
+	if q.shouldInclude("enu") {
+		enu := fmt.Sprintf(`{"east":%.2f,"north":%.2f,"up":%.2f}`, east, north, up)
+		if b.settings.Geofence.Radius <= 0 {
+			frame.Fields = append(frame.Fields, data.NewField("enu", nil, []string{enu}))
+		} else {
+			var v *string
+			if !outside {
+				v = &enu
+			}
+			frame.Fields = append(frame.Fields, data.NewField("enu", nil, []*string{v}))
+		}
+	}
+	if q.shouldInclude("downrange") || q.shouldInclude("crossrange") {
+		downrange, crossrange := b.ranges.Update(b.origin)
+		if q.shouldInclude("downrange") {
+			frame.Fields = append(frame.Fields, b.position("downrange", downrange, outside))
+		}
+		if q.shouldInclude("crossrange") {
+			frame.Fields = append(frame.Fields, b.position("crossrange", crossrange, outside))
+		}
+	}
+	if q.shouldInclude("distance") {
+		frame.Fields = append(frame.Fields, b.position("distance", b.origin.Distance(), outside))
+	}
+	pathDistance := b.path.Update(b.origin)
+	if q.shouldInclude("pathDistance") {
+		frame.Fields = append(frame.Fields, b.position("pathDistance", pathDistance, outside))
+	}
+	if q.shouldInclude("gpsFix") {
+		frame.Fields = append(frame.Fields, data.NewField("gpsFix", nil, []bool{!packet.GPSNoFix}))
+	}
+	if q.shouldInclude("deadReckoned") {
+		frame.Fields = append(frame.Fields, data.NewField("deadReckoned", nil, []bool{packet.DeadReckoned}))
+	}
+
+	// Course over ground, for rotating geomap markers.
+	heading := b.course.Update(packet)
+	if q.shouldInclude("heading") {
+		frame.Fields = append(frame.Fields, b.number("heading", heading))
+	}
+	if q.shouldInclude("state") {
+		state := packet.State
+		if b.sequence != nil {
+			state = b.sequence.Next(state)
+		}
+		frame.Fields = append(frame.Fields, data.NewField("state", nil, []int64{int64(state)}))
+	}
+	if q.shouldInclude("detectedState") {
+		var detected *int64
+		if packet.DetectedState != nil {
+			v := int64(*packet.DetectedState)
+			detected = &v
+		}
+		frame.Fields = append(frame.Fields, data.NewField("detectedState", nil, []*int64{detected}))
+	}
+	if q.shouldInclude("pitch") {
+		frame.Fields = append(frame.Fields, b.number("pitch", packet.Pitch))
+	}
+	if q.shouldInclude("roll") {
+		frame.Fields = append(frame.Fields, b.number("roll", packet.Roll))
+	}
+	if q.shouldInclude("yaw") {
+		frame.Fields = append(frame.Fields, b.number("yaw", packet.Yaw))
+	}
+	if q.shouldInclude("tiltAngle") {
+		frame.Fields = append(frame.Fields, b.number("tiltAngle", TiltFromVertical(packet.Pitch, packet.Roll)))
+	}
+	stability := b.stable.Update(b.prev, packet)
+	if q.shouldInclude("stabilityIndex") {
+		frame.Fields = append(frame.Fields, b.number("stabilityIndex", stability))
+	}
+	for _, rate := range []struct {
+		name  string
+		angle func(TelemetryPacket) float64
+	}{
+		{"pitchRate", func(p TelemetryPacket) float64 { return p.Pitch }},
+		{"rollRate", func(p TelemetryPacket) float64 { return p.Roll }},
+		{"yawRate", func(p TelemetryPacket) float64 { return p.Yaw }},
+	} {
+		if q.shouldInclude(rate.name) {
+			var v float64
+			if b.prev != nil {
+				v = angularRate(*b.prev, packet, rate.angle)
+			}
+			frame.Fields = append(frame.Fields, b.number(rate.name, v))
+		}
+	}
+	if q.shouldInclude("attitude") {
+		attitude := FormatAttitude(packet.Pitch, packet.Roll, packet.Yaw, q.AttitudePrecision)
+		frame.Fields = append(frame.Fields, data.NewField("attitude", nil, []string{attitude}))
+	}
+	if q.shouldInclude("gforce") {
+		frame.Fields = append(frame.Fields, b.number("gforce", packet.GForce))
+	}
+	b.gforce.Add(packet.GForce)
+	if q.shouldInclude("gforceAvg") {
+		frame.Fields = append(frame.Fields, b.number("gforceAvg", b.gforce.Mean()))
+	}
+	if q.shouldInclude("gforceClamped") {
+		frame.Fields = append(frame.Fields, data.NewField("gforceClamped", nil, []bool{packet.GForceClamped}))
+	}
+	if q.shouldInclude("signal") {
+		frame.Fields = append(frame.Fields, data.NewField("signal", nil, []int64{int64(packet.Signal)}))
+		for _, name := range b.receivers {
+			var signal *int64
+			if v, ok := packet.Receivers[name]; ok {
+				s := int64(v)
+				signal = &s
+			}
+			frame.Fields = append(frame.Fields, data.NewField("signal", data.Labels{"receiver": name}, []*int64{signal}))
+		}
+	}
+	if q.shouldInclude("signalPercent") {
+		percent := SignalPercent(packet.Signal, b.settings.Signal.MinDBm, b.settings.Signal.MaxDBm)
+		frame.Fields = append(frame.Fields, b.number("signalPercent", percent))
+	}
+	if q.shouldInclude("packetLossPercent") {
+		frame.Fields = append(frame.Fields, b.number("packetLossPercent", b.loss.Update(packet)))
+	}
+
+	// Mach from the vertical rate, which dominates during boost.
+	if q.shouldInclude("mach") {
+		frame.Fields = append(frame.Fields, b.number("mach", MachNumber(vspeed, packet.Altitude)))
+	}
+
+	// Rail exit velocity, only on the packet that clears the rail.
+	railVelocity, cleared := b.rail.Update(packet, vspeed)
+	if q.shouldInclude("railExitVelocity") {
+		frame.Fields = append(frame.Fields, b.nullableNumber("railExitVelocity", railVelocity, cleared))
+	}
+
+	// Descent rate, positive downwards, null outside of the descent. It is
+	// colored red beyond the safety envelope's MaxDescentRate.
+	if q.shouldInclude("descentRateFpm") {
+		field := b.nullableNumber("descentRateFpm", -vspeed, packet.State == DESCENDING)
+		if limit := b.settings.Safety.MaxDescentRate; limit > 0 {
+			u, _ := b.unit("descentRateFpm")
+			field.Config.Thresholds = &data.ThresholdsConfig{
+				Mode: data.ThresholdsModeAbsolute,
+				Steps: []data.Threshold{
+					data.NewThreshold(math.Inf(-1), "green", ""),
+					data.NewThreshold(limit*u.Scale, "red", ""),
+				},
+			}
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	// Landing prediction, refined every packet during descent. Outside of it
+	// the ETA is 0 and the predicted spot is the current position. Through a
+	// GPS dropout it extrapolates from the last fix.
+	fix, speed := b.track.Update(packet)
+	if q.shouldInclude("landingETA") || q.shouldInclude("predictedLandingLat") || q.shouldInclude("predictedLandingLon") {
+		var eta float64
+		if packet.State == DESCENDING {
+			eta = LandingETA(packet.Altitude, vspeed)
+		}
+		lat, lon := PredictLanding(fix.Latitude, fix.Longitude, speed, heading, b.settings.Wind, eta)
+		if q.shouldInclude("landingETA") {
+			frame.Fields = append(frame.Fields, b.number("landingETA", eta))
+		}
+		if q.shouldInclude("predictedLandingLat") {
+			frame.Fields = append(frame.Fields, b.position("predictedLandingLat", lat, outside))
+		}
+		if q.shouldInclude("predictedLandingLon") {
+			frame.Fields = append(frame.Fields, b.position("predictedLandingLon", lon, outside))
+		}
+	}
+
+	progress := b.progress.Update(packet.State, packet.Altitude, vspeed)
+	if q.shouldInclude("flightProgress") {
+		frame.Fields = append(frame.Fields, b.number("flightProgress", progress))
+	}
+	apogee := b.apogee.Update(packet.State, packet.Altitude, vspeed)
+	if q.shouldInclude("apogeeEstimate") {
+		frame.Fields = append(frame.Fields, b.number("apogeeEstimate", apogee))
+	}
+	times := b.phases.Update(packet, vspeed)
+	for _, phase := range []struct {
+		name string
+		v    float64
+	}{{"timeInBoost", times.Boost}, {"timeInCoast", times.Coast}, {"timeInDescent", times.Descent}} {
+		if q.shouldInclude(phase.name) {
+			frame.Fields = append(frame.Fields, b.number(phase.name, phase.v))
+		}
+	}
+
+	// Cross-check the altitude against the reported velocity. Without a
+	// velocity on both packets there is nothing to check, and both fields are
+	// null rather than claiming consistency.
+	var divergence float64
+	checked := b.prev != nil && b.prev.Velocity != nil && packet.Velocity != nil
+	if checked {
+		divergence = VelocityDivergence(*b.prev, packet)
+	}
+	if q.shouldInclude("dataConsistency") {
+		score := ConsistencyScore(divergence, b.settings.Safety.MaxVelocityDivergence)
+		frame.Fields = append(frame.Fields, b.nullableNumber("dataConsistency", score, checked))
+	}
+	if q.shouldInclude("dataInconsistent") {
+		var inconsistent *bool
+		if checked {
+			v := divergence > b.settings.Safety.MaxVelocityDivergence
+			inconsistent = &v
+		}
+		frame.Fields = append(frame.Fields, data.NewField("dataInconsistent", nil, []*bool{inconsistent}))
+	}
+
+	safety := CheckSafety(packet, vspeed, b.settings.Safety)
+	if q.shouldInclude("sensorFailed") {
+		frame.Fields = append(frame.Fields, data.NewField("sensorFailed", nil, []bool{packet.SensorFailed}))
+	}
+	if q.shouldInclude("altitudeSaturated") {
+		frame.Fields = append(frame.Fields, data.NewField("altitudeSaturated", nil, []bool{saturated}))
+	}
+	if q.shouldInclude("source") {
+		frame.Fields = append(frame.Fields, data.NewField("source", nil, []string{packet.Source}))
+	}
+
+	if q.shouldInclude("overGForce") {
+		frame.Fields = append(frame.Fields, data.NewField("overGForce", nil, []bool{safety.OverGForce}))
+	}
+	if q.shouldInclude("descentWarning") {
+		frame.Fields = append(frame.Fields, data.NewField("descentWarning", nil, []bool{safety.DescentWarning}))
+	}
+	if q.shouldInclude("ceilingExceeded") {
+		frame.Fields = append(frame.Fields, data.NewField("ceilingExceeded", nil, []bool{safety.CeilingExceeded}))
+	}
+	if q.shouldInclude("signalCritical") {
+		frame.Fields = append(frame.Fields, data.NewField("signalCritical", nil, []bool{safety.SignalCritical}))
+	}
+	if q.shouldInclude("gpsLost") {
+		frame.Fields = append(frame.Fields, data.NewField("gpsLost", nil, []bool{safety.GPSLost}))
+	}
+	if q.shouldInclude("outsideGeofence") {
+		frame.Fields = append(frame.Fields, data.NewField("outsideGeofence", nil, []bool{outside}))
+	}
+
+	b.prev = &packet
+
+	return frame
+}
+
+// number builds a single value float field, converted to the unit the query
+// requested for it in the field registry.
+func (b *frameBuilder) number(name string, v float64) *data.Field {
+	v = b.deadband(name, v)
+	field := data.NewField(name, nil, []float64{v})
+	if u, ok := b.unit(name); ok {
+		field.Set(0, v*u.Scale)
+		field.Config = &data.FieldConfig{Unit: u.GrafanaUnit}
+	}
+	return field
+}
+
+// unit returns the unit the query requested for a field with unit choices.
+func (b *frameBuilder) unit(name string) (Unit, bool) {
+	info, ok := lookupField(name)
+	if !ok || len(info.Units) == 0 {
+		return Unit{}, false
+	}
+	return findUnit(info.Units, b.q.Units[name]), true
+}
+
+// nullableNumber is number for a field that is null unless ok.
+func (b *frameBuilder) nullableNumber(name string, v float64, ok bool) *data.Field {
+	number := b.number(name, v)
+	field := data.NewField(name, nil, []*float64{nil})
+	field.Config = number.Config
+	if ok {
+		v := number.At(0).(float64)
+		field.Set(0, &v)
+	}
+	return field
+}
+
+// position builds a field that gives away where the rocket is: a coordinate
+// or an offset from the pad. With a geofence configured the field is nullable
+// and null while the rocket is outside the fence.
+func (b *frameBuilder) position(name string, v float64, outside bool) *data.Field {
+	if b.settings.Geofence.Radius <= 0 {
+		return b.number(name, v)
+	}
+	return b.nullableNumber(name, v, !outside)
+}
+
+// flightLeg reports whether a packet belongs to the ascent or the descent,
+// by reported state or by the sign of the vertical speed. Apex belongs to
+// both so the two legs join up on a chart; the pad belongs to neither.
+func flightLeg(packet TelemetryPacket, vspeed float64, by string) (ascending, descending bool) {
+	if by == PhaseByVelocity {
+		if packet.Velocity != nil {
+			vspeed = *packet.Velocity
+		}
+		return vspeed > 0, vspeed < 0
+	}
+	switch packet.State {
+	case LAUNCHING:
+		return true, false
+	case APEX:
+		return true, true
+	case DESCENDING:
+		return false, true
+	}
+	return false, false
+}
+
+// deadband returns the value last emitted for name while v stays within the
+// field's configured deadband of it.
+func (b *frameBuilder) deadband(name string, v float64) float64 {
+	band := b.q.Deadband[name]
+	if band <= 0 {
+		return v
+	}
+	if held, ok := b.held[name]; ok && math.Abs(v-held) < band {
+		return held
+	}
+	if b.held == nil {
+		b.held = map[string]float64{}
+	}
+	b.held[name] = v
+	return v
+}
@@ -0,0 +1,251 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func TestDeadbandHoldsSmallChanges(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"pitch", "roll"}
+	q.Deadband = map[string]float64{"pitch": 0.5}
+	b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+	for _, tc := range []struct {
+		pitch, roll    float64
+		want, wantRoll float64
+	}{
+		{pitch: 80, roll: 1, want: 80, wantRoll: 1},
+		{pitch: 80.3, roll: 1.1, want: 80, wantRoll: 1.1},
+		{pitch: 79.6, roll: 1.2, want: 80, wantRoll: 1.2},
+		{pitch: 80.6, roll: 1.3, want: 80.6, wantRoll: 1.3},
+		{pitch: 80.2, roll: 1.4, want: 80.6, wantRoll: 1.4},
+	} {
+		frame := b.Build(TelemetryPacket{Pitch: tc.pitch, Roll: tc.roll})
+		pitch, _ := frame.FieldByName("pitch")
+		roll, _ := frame.FieldByName("roll")
+		if got := pitch.At(0).(float64); got != tc.want {
+			t.Errorf("pitch %v: emitted %v, want %v", tc.pitch, got, tc.want)
+		}
+		if got := roll.At(0).(float64); got != tc.wantRoll {
+			t.Errorf("roll %v: emitted %v, want it unfiltered", tc.roll, got)
+		}
+	}
+}
+
+func TestAltitudeSplitByPhase(t *testing.T) {
+	velocity := func(v float64) *float64 { return &v }
+	packets := []TelemetryPacket{
+		{Timestamp: 0, Altitude: 0, State: LANDED},
+		{Timestamp: 1000, Altitude: 100, State: LAUNCHING, Velocity: velocity(100)},
+		{Timestamp: 2000, Altitude: 150, State: APEX, Velocity: velocity(0)},
+		{Timestamp: 3000, Altitude: 140, State: DESCENDING, Velocity: velocity(-10)},
+	}
+
+	for _, tc := range []struct {
+		by              string
+		ascent, descent []any
+	}{
+		{
+			by:      PhaseByState,
+			ascent:  []any{nil, 100.0, 150.0, nil},
+			descent: []any{nil, nil, 150.0, 140.0},
+		},
+		{
+			by:      PhaseByVelocity,
+			ascent:  []any{nil, 100.0, nil, nil},
+			descent: []any{nil, nil, nil, 140.0},
+		},
+	} {
+		q := defaultQuery()
+		q.Fields = []string{"altitudeAscent", "altitudeDescent"}
+		q.PhaseBy = tc.by
+		frame := newFrameBuilder(q, models.DefaultPluginSettings()).BuildAll(packets)
+
+		for name, want := range map[string][]any{"altitudeAscent": tc.ascent, "altitudeDescent": tc.descent} {
+			field, _ := frame.FieldByName(name)
+			for i, w := range want {
+				got, ok := field.ConcreteAt(i)
+				if !ok {
+					got = nil
+				}
+				if got != w {
+					t.Errorf("%s: %s[%d] = %v, want %v", tc.by, name, i, got, w)
+				}
+			}
+		}
+	}
+}
+
+func TestGeofenceHidesPosition(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	settings.Geofence = models.GeofenceConfig{Latitude: 37.7749, Longitude: -122.4194, Radius: 500}
+	q := defaultQuery()
+	q.Fields = []string{"latitude", "longitude", "outsideGeofence"}
+
+	frame := newFrameBuilder(q, settings).BuildAll([]TelemetryPacket{
+		{Timestamp: 0, GPS: GPS{Latitude: 37.7749, Longitude: -122.4194}},
+		{Timestamp: 1000, GPS: GPS{Latitude: 37.7849, Longitude: -122.4194}}, // ~1.1km north
+	})
+
+	lat, _ := frame.FieldByName("latitude")
+	flag, _ := frame.FieldByName("outsideGeofence")
+	if v, ok := lat.ConcreteAt(0); !ok || v != 37.7749 || flag.At(0).(bool) {
+		t.Errorf("inside the fence: latitude %v, outside %v", v, flag.At(0))
+	}
+	if v, ok := lat.ConcreteAt(1); ok || !flag.At(1).(bool) {
+		t.Errorf("outside the fence: latitude %v, outside %v, want null and flagged", v, flag.At(1))
+	}
+}
+
+func TestGeofenceHidesPositionDerivedFields(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	settings.Geofence = models.GeofenceConfig{Latitude: 37.7749, Longitude: -122.4194, Radius: 500}
+	frame := newFrameBuilder(defaultQuery(), settings).BuildAll([]TelemetryPacket{
+		{Timestamp: 0, State: LANDED, GPS: GPS{Latitude: 37.7749, Longitude: -122.4194}},
+		{Timestamp: 1000, Altitude: 100, State: LAUNCHING, GPS: GPS{Latitude: 37.7760, Longitude: -122.4194}},
+		{Timestamp: 2000, Altitude: 200, State: LAUNCHING, GPS: GPS{Latitude: 37.7849, Longitude: -122.4194}},
+	})
+
+	for _, name := range []string{
+		"latitude", "longitude", "east", "north", "enu", "downrange", "crossrange",
+		"distance", "pathDistance", "predictedLandingLat", "predictedLandingLon",
+	} {
+		field, _ := frame.FieldByName(name)
+		if field == nil {
+			t.Errorf("%s: missing", name)
+			continue
+		}
+		if _, ok := field.ConcreteAt(1); !ok {
+			t.Errorf("%s: null inside the fence", name)
+		}
+		if v, ok := field.ConcreteAt(2); ok {
+			t.Errorf("%s: %v outside the fence, want null", name, v)
+		}
+	}
+}
+
+func TestLandingPredictionThroughDropout(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"predictedLandingLat", "predictedLandingLon"}
+	frame := newFrameBuilder(q, models.DefaultPluginSettings()).BuildAll([]TelemetryPacket{
+		{Timestamp: 0, Altitude: 300, State: DESCENDING, GPS: GPS{Latitude: 37.7749, Longitude: -122.4194}},
+		{Timestamp: 1000, Altitude: 290, State: DESCENDING, GPS: GPS{Latitude: 37.7750, Longitude: -122.4194}},
+		{Timestamp: 2000, Altitude: 280, State: DESCENDING, GPSNoFix: true},
+		{Timestamp: 3000, Altitude: 270, State: DESCENDING, GPS: GPS{Latitude: 37.7751, Longitude: -122.4194}},
+	})
+
+	lat, _ := frame.FieldByName("predictedLandingLat")
+	lon, _ := frame.FieldByName("predictedLandingLon")
+	// Drifting north at ~11 m/s with 28 s to go, the rocket lands ~300 m, or
+	// 0.003°, north of the last fix.
+	for row := 1; row < 4; row++ {
+		la, _ := lat.ConcreteAt(row)
+		lo, _ := lon.ConcreteAt(row)
+		if math.Abs(la.(float64)-37.778) > 0.002 || math.Abs(lo.(float64)+122.4194) > 0.001 {
+			t.Errorf("row %d: predicted landing at %v, %v, want near 37.778, -122.4194", row, la, lo)
+		}
+	}
+}
+
+func TestDescentRateFpm(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"descentRateFpm"}
+	frame := newFrameBuilder(q, models.DefaultPluginSettings()).BuildAll([]TelemetryPacket{
+		{Timestamp: 0, Altitude: 300, State: APEX},
+		{Timestamp: 1000, Altitude: 295, State: DESCENDING},
+	})
+
+	field, _ := frame.FieldByName("descentRateFpm")
+	if _, ok := field.ConcreteAt(0); ok {
+		t.Error("descent rate emitted outside of the descent")
+	}
+	if v, _ := field.ConcreteAt(1); math.Abs(v.(float64)-984.252) > 0.01 {
+		t.Errorf("5 m/s: got %v ft/min, want 984.252", v)
+	}
+	if field.Config.Unit != "suffix: ft/min" || field.Config.Thresholds == nil {
+		t.Errorf("got config %+v, want ft/min with thresholds", field.Config)
+	}
+	if red := field.Config.Thresholds.Steps[1].Value; math.Abs(float64(red)-30*196.8504) > 0.01 {
+		t.Errorf("red threshold at %v ft/min, want MaxDescentRate converted", red)
+	}
+}
+
+func TestSignalPerReceiver(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	settings.Sources = []models.SourceConfig{{Name: "A", Type: "serial"}, {Type: "file"}}
+	q := defaultQuery()
+	q.Fields = []string{"signal"}
+	q.SignalPerReceiver = true
+
+	frame := newFrameBuilder(q, settings).Build(TelemetryPacket{Signal: -60, Receivers: map[string]int{"A": -60}})
+	if len(frame.Fields) != 4 {
+		t.Fatalf("got %d fields, want time, merged signal and one per receiver", len(frame.Fields))
+	}
+	for i, want := range []struct {
+		receiver string
+		signal   any
+	}{{"A", int64(-60)}, {"file-1", nil}} {
+		f := frame.Fields[2+i]
+		got, ok := f.ConcreteAt(0)
+		if !ok {
+			got = nil
+		}
+		if f.Name != "signal" || f.Labels["receiver"] != want.receiver || got != want.signal {
+			t.Errorf("field %d: %s%v = %v, want receiver %s at %v", i, f.Name, f.Labels, got, want.receiver, want.signal)
+		}
+	}
+}
+
+func TestLengthFieldUnits(t *testing.T) {
+	pad := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	lat, lon := offsetLatLon(pad.Latitude, pad.Longitude, 300, 400)
+	turn, turnLon := offsetLatLon(pad.Latitude, pad.Longitude, 0, 400)
+	packets := []TelemetryPacket{
+		{Timestamp: 0, GPS: pad, State: LANDED},
+		{Timestamp: 1000, GPS: pad, State: LAUNCHING},
+		{Timestamp: 2000, GPS: GPS{Latitude: turn, Longitude: turnLon}, Altitude: 600, State: LAUNCHING},
+		{Timestamp: 3000, GPS: GPS{Latitude: lat, Longitude: lon}, Altitude: 1000, State: APEX},
+	}
+	east := 90.0
+	// SI values at the last packet.
+	want := map[string]float64{
+		"east":         400,
+		"north":        300,
+		"up":           1000,
+		"downrange":    400,
+		"crossrange":   -300,
+		"distance":     500,
+		"pathDistance": 700,
+	}
+
+	for _, unit := range lengthUnits {
+		q := defaultQuery()
+		q.LaunchAzimuth = &east
+		q.Units = map[string]string{}
+		for name := range want {
+			q.Fields = append(q.Fields, name)
+			q.Units[name] = unit.ID
+		}
+		b := newFrameBuilder(q, models.DefaultPluginSettings())
+		var frame *data.Frame
+		for _, p := range packets {
+			frame = b.Build(p)
+		}
+		for name, si := range want {
+			field, _ := frame.FieldByName(name)
+			if field == nil {
+				t.Fatalf("%s: field missing", name)
+			}
+			if field.Config == nil || field.Config.Unit != unit.GrafanaUnit {
+				t.Errorf("%s in %s: unit %+v, want %s", name, unit.ID, field.Config, unit.GrafanaUnit)
+			}
+			if got := field.At(0).(float64); math.Abs(got-si*unit.Scale) > 0.05*unit.Scale {
+				t.Errorf("%s in %s: got %v, want %v", name, unit.ID, got, si*unit.Scale)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"math"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+const earthRadius = 6371000.0 // mean radius, m
+
+func toRadians(deg float64) float64 { return deg * math.Pi / 180 }
+func toDegrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// offsetLatLon moves a position by north and east meters, using a local flat
+// earth approximation that is fine over rocket flight distances.
+func offsetLatLon(lat, lon, north, east float64) (float64, float64) {
+	dLat := toDegrees(north / earthRadius)
+	dLon := toDegrees(east / (earthRadius * math.Cos(toRadians(lat))))
+	return lat + dLat, lon + dLon
+}
+
+// Haversine returns the great circle distance in meters between two points.
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// outsideGeofence reports whether a fix lies outside an enabled geofence.
+func outsideGeofence(fix GPS, fence models.GeofenceConfig) bool {
+	if fence.Radius <= 0 {
+		return false
+	}
+	return Haversine(fence.Latitude, fence.Longitude, fix.Latitude, fix.Longitude) > fence.Radius
+}
+
+// Bearing returns the initial bearing in degrees clockwise from north, in
+// [0, 360), to travel from the first point to the second.
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	φ1, φ2 := toRadians(lat1), toRadians(lat2)
+	dLon := toRadians(lon2 - lon1)
+	y := math.Sin(dLon) * math.Cos(φ2)
+	x := math.Cos(φ1)*math.Sin(φ2) - math.Sin(φ1)*math.Cos(φ2)*math.Cos(dLon)
+	return wrapDegrees(toDegrees(math.Atan2(y, x)))
+}
+
+// minHeadingDistance is how far the rocket must move between fixes before a
+// new course over ground is computed, to avoid spinning on GPS jitter.
+const minHeadingDistance = 0.5 // m
+
+// courseTracker derives the course over ground from consecutive GPS fixes,
+// holding the last heading while stationary or without a fix.
+type courseTracker struct {
+	heading float64
+	prev    *GPS
+}
+
+func (c *courseTracker) Update(packet TelemetryPacket) float64 {
+	if gpsLost(packet) {
+		return c.heading
+	}
+	fix := packet.GPS
+	if c.prev == nil {
+		c.prev = &fix
+		return c.heading
+	}
+	if Haversine(c.prev.Latitude, c.prev.Longitude, fix.Latitude, fix.Longitude) < minHeadingDistance {
+		return c.heading
+	}
+	c.heading = Bearing(c.prev.Latitude, c.prev.Longitude, fix.Latitude, fix.Longitude)
+	c.prev = &fix
+	return c.heading
+}
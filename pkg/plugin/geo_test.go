@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	// One degree of latitude is about 111.2 km.
+	if got := Haversine(37, -122, 38, -122); math.Abs(got-111195) > 10 {
+		t.Errorf("got %v m, want ~111195", got)
+	}
+	if got := Haversine(37, -122, 37, -122); got != 0 {
+		t.Errorf("same point: got %v, want 0", got)
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		name       string
+		lat2, lon2 float64
+		want, tol  float64
+	}{
+		{"north", 38, -122, 0, 1e-9},
+		{"east", 37, -121.99, 90, 0.01},
+		{"south", 36, -122, 180, 1e-9},
+		{"west", 37, -122.01, 270, 0.01},
+	}
+	for _, tt := range tests {
+		if got := Bearing(37, -122, tt.lat2, tt.lon2); math.Abs(got-tt.want) > tt.tol {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCourseTrackerHoldsWhenStationary(t *testing.T) {
+	var c courseTracker
+	c.Update(TelemetryPacket{GPS: GPS{Latitude: 37, Longitude: -122}})
+	if got := c.Update(TelemetryPacket{GPS: GPS{Latitude: 37, Longitude: -121.999}}); math.Abs(got-90) > 0.01 {
+		t.Fatalf("got heading %v, want ~90", got)
+	}
+	if got := c.Update(TelemetryPacket{GPS: GPS{Latitude: 37, Longitude: -121.999}}); math.Abs(got-90) > 0.01 {
+		t.Fatalf("stationary: got heading %v, want held ~90", got)
+	}
+}
+
+func TestCourseTrackerHoldsThroughDropout(t *testing.T) {
+	var c courseTracker
+	c.Update(TelemetryPacket{GPS: GPS{Latitude: 37, Longitude: -122}})
+	c.Update(TelemetryPacket{GPS: GPS{Latitude: 37, Longitude: -121.999}})
+	for _, lost := range []TelemetryPacket{{}, {GPS: GPS{Latitude: 37, Longitude: -121.998}, GPSNoFix: true}} {
+		if got := c.Update(lost); math.Abs(got-90) > 0.01 {
+			t.Errorf("dropout %+v: got heading %v, want held ~90", lost, got)
+		}
+	}
+	// Back from the dropout, the course picks up from the last good fix.
+	if got := c.Update(TelemetryPacket{GPS: GPS{Latitude: 37.001, Longitude: -121.999}}); math.Abs(got) > 0.01 {
+		t.Errorf("after dropout: got heading %v, want ~0", got)
+	}
+}
@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"math"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// GForce derives the g-force of a simulated motion by method, see the
+// models.GForce constants, from the vertical speed in m/s and the horizontal
+// and vertical acceleration in m/s². An empty method is GForceAcceleration.
+func GForce(method string, verticalSpeed, hAccel, vAccel float64) float64 {
+	switch method {
+	case models.GForceIMU:
+		return math.Hypot(hAccel, vAccel+standardGravity) / standardGravity
+	case models.GForceApprox:
+		return 1 + verticalSpeed/standardGravity/10
+	default:
+		return 1 + vAccel/standardGravity
+	}
+}
+
+// accelerationWindow is the span the simulated acceleration is averaged over.
+// The simulation applies a motor burn as a single change in velocity, which
+// over one tick would read as an ever larger spike the shorter the tick.
+// Averaged over a fixed span it reads as a burn of that length instead, the
+// same at any cadence.
+const accelerationWindow = 2 * time.Second
+
+// accelerometer derives the acceleration of the simulated motion over the
+// accelerationWindow.
+type accelerometer struct {
+	samples []velocitySample
+}
+
+type velocitySample struct {
+	t    time.Time
+	v, h float64
+}
+
+// Update records the vertical and horizontal velocity at now and returns the
+// average vertical and horizontal acceleration over the window leading up to
+// it. Before the window has filled the rocket is taken to have been moving at
+// the first velocity recorded.
+func (a *accelerometer) Update(now time.Time, v, h float64) (vAccel, hAccel float64) {
+	a.samples = append(a.samples, velocitySample{now, v, h})
+	cutoff := now.Add(-accelerationWindow)
+	drop := 0
+	for drop+1 < len(a.samples) && !a.samples[drop+1].t.After(cutoff) {
+		drop++
+	}
+	a.samples = append(a.samples[:0], a.samples[drop:]...)
+
+	first := a.samples[0]
+	dt := math.Max(now.Sub(first.t).Seconds(), accelerationWindow.Seconds())
+	return (v - first.v) / dt, (h - first.h) / dt
+}
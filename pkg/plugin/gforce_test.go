@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestGForce(t *testing.T) {
+	g := standardGravity
+	tests := []struct {
+		name                  string
+		method                string
+		speed, hAccel, vAccel float64
+		want                  float64
+	}{
+		{"at rest", models.GForceAcceleration, 0, 0, 0, 1},
+		{"coasting", models.GForceAcceleration, 50, 0, -g, 0},
+		{"boost", models.GForceAcceleration, 50, 3 * g, 4 * g, 5},
+		{"default method", "", 0, 0, 2 * g, 3},
+		{"imu at rest", models.GForceIMU, 0, 0, 0, 1},
+		{"imu coasting", models.GForceIMU, 50, 0, -g, 0},
+		{"imu boost", models.GForceIMU, 50, 3 * g, 3 * g, 5},
+		{"imu braking", models.GForceIMU, -50, 0, -3 * g, 2},
+		{"approx", models.GForceApprox, 98.0665, 0, 0, 2},
+		{"approx ignores acceleration", models.GForceApprox, 0, 0, 5 * g, 1},
+	}
+	for _, tt := range tests {
+		if got := GForce(tt.method, tt.speed, tt.hAccel, tt.vAccel); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSimulationGForceMethod(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.LaunchDelay = 0
+	start := time.UnixMilli(0)
+	sim := NewRocketSimulationAt(start, cfg)
+	sim.TickAt(start)
+	// The motor fires on the first tick after the launch delay.
+	if boost := sim.TickAt(start.Add(time.Second)); boost.GForce < 5 {
+		t.Errorf("boost: got %v g, want the kick of the motor", boost.GForce)
+	}
+	// Coasting once the burn is out of the window, the accelerometer reads
+	// close to free fall.
+	sim.TickAt(start.Add(2 * time.Second))
+	packet := sim.TickAt(start.Add(3500 * time.Millisecond))
+	if packet.State != LAUNCHING || math.Abs(packet.GForce) > 0.01 {
+		t.Errorf("coasting: got %v g in state %v, want 0 g", packet.GForce, packet.State)
+	}
+
+	cfg.GForceMethod = "centrifuge"
+	if _, err := newSimulator(start, cfg); err == nil {
+		t.Error("unknown gforce method accepted")
+	}
+}
+
+func TestSimulationGForceWithinSafety(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	for _, cadence := range []time.Duration{500 * time.Millisecond, 100 * time.Millisecond} {
+		cfg := settings.Simulation
+		cfg.Seed = 1
+		start := time.UnixMilli(0)
+		sim := NewRocketSimulationAt(start, cfg)
+		var peak float64
+		launched := false
+		for now := start; now.Before(start.Add(10 * time.Minute)); now = now.Add(cadence) {
+			packet := sim.TickAt(now)
+			peak = math.Max(peak, packet.GForce)
+			if packet.State == LAUNCHING {
+				launched = true
+			} else if launched && packet.State == LANDED {
+				break
+			}
+		}
+		if !launched {
+			t.Fatalf("%v: the rocket never launched", cadence)
+		}
+		if peak >= settings.Safety.MaxGForce {
+			t.Errorf("%v ticks: peak %v g, want under the default limit of %v g", cadence, peak, settings.Safety.MaxGForce)
+		}
+	}
+}
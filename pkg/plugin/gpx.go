@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+const gpxNamespace = "http://www.topografix.com/GPX/1/1"
+
+// gpxDocument is the subset of GPX 1.1 needed for a single track.
+type gpxDocument struct {
+	XMLName xml.Name `xml:"gpx"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Track   gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+// gpxPoint holds its coordinates as strings: GPX wants plain decimals, which
+// encoding/xml does not guarantee for floats.
+type gpxPoint struct {
+	Lat       string `xml:"lat,attr"`
+	Lon       string `xml:"lon,attr"`
+	Elevation string `xml:"ele"`
+	Time      string `xml:"time"`
+}
+
+// MarshalGPX encodes the GPS track of a flight as a GPX 1.1 document, with
+// the altitude as elevation. Packets without a fix are left out and split the
+// track into segments, so mapping apps do not draw a line across the gap.
+func MarshalGPX(f Flight) ([]byte, error) {
+	doc := gpxDocument{
+		Xmlns:   gpxNamespace,
+		Version: "1.1",
+		Creator: "rocket-telemetry",
+		Track:   gpxTrack{Name: f.ID},
+	}
+
+	var segment gpxSegment
+	flush := func() {
+		if len(segment.Points) > 0 {
+			doc.Track.Segments = append(doc.Track.Segments, segment)
+			segment = gpxSegment{}
+		}
+	}
+	for _, p := range f.Packets {
+		if gpsLost(p) {
+			flush()
+			continue
+		}
+		segment.Points = append(segment.Points, gpxPoint{
+			Lat:       formatDecimal(p.GPS.Latitude),
+			Lon:       formatDecimal(p.GPS.Longitude),
+			Elevation: formatDecimal(p.Altitude),
+			Time:      time.UnixMilli(int64(p.Timestamp)).UTC().Format(time.RFC3339Nano),
+		})
+	}
+	flush()
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// formatDecimal formats v as a plain decimal, never in exponent notation.
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestMarshalGPX(t *testing.T) {
+	doc, err := MarshalGPX(Flight{ID: "alpha", Packets: []TelemetryPacket{
+		{Timestamp: 1_700_000_000_000, Altitude: 12.5, GPS: GPS{Latitude: 37.7749, Longitude: -122.4194}},
+		{Timestamp: 1_700_000_000_500, Altitude: 80, GPSNoFix: true},
+		{Timestamp: 1_700_000_001_000, Altitude: 150, GPS: GPS{Latitude: 37.77495, Longitude: -122.00001}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(doc), xml.Header) {
+		t.Errorf("document does not start with an XML declaration: %.40s", doc)
+	}
+	if strings.Contains(string(doc), "e-") {
+		t.Errorf("document holds exponent notation:\n%s", doc)
+	}
+
+	var got gpxDocument
+	if err := xml.Unmarshal(doc, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.XMLName.Space != gpxNamespace || got.Version != "1.1" || got.Track.Name != "alpha" {
+		t.Fatalf("got header %+v version %q track %q", got.XMLName, got.Version, got.Track.Name)
+	}
+	if len(got.Track.Segments) != 2 {
+		t.Fatalf("got %d segments, want the fix loss to split the track in 2", len(got.Track.Segments))
+	}
+	first := got.Track.Segments[0].Points[0]
+	want := gpxPoint{Lat: "37.7749", Lon: "-122.4194", Elevation: "12.5", Time: "2023-11-14T22:13:20Z"}
+	if first != want {
+		t.Errorf("got point %+v, want %+v", first, want)
+	}
+}
+
+func TestFlightExportGPX(t *testing.T) {
+	dir := t.TempDir()
+	writeFlight(t, dir, "alpha.log", 0, 100, 250)
+	settings := models.DefaultPluginSettings()
+	settings.FlightDir = dir
+	d := &Datasource{settings: settings}
+
+	for path, want := range map[string]int{
+		"alpha.gpx":      http.StatusOK,
+		"bravo.gpx":      http.StatusNotFound,
+		"alpha.log":      http.StatusNotFound,
+		"..%2Falpha.gpx": http.StatusNotFound,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/flight/"+path, nil)
+		file := strings.ReplaceAll(path, "%2F", "/")
+		req.SetPathValue("file", file)
+		rec := httptest.NewRecorder()
+		d.handleFlightExport(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: got status %d, want %d", path, rec.Code, want)
+		}
+		if want == http.StatusOK && rec.Header().Get("Content-Type") != "application/gpx+xml" {
+			t.Errorf("%s: got content type %q", path, rec.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	historyInterval = 500 * time.Millisecond
+	// maxHistoryTicks bounds how much simulated history a single query can
+	// generate, about seven hours at the stream rate.
+	maxHistoryTicks = 50000
+)
+
+// errNoHistory is returned for a source that keeps no history to query.
+var errNoHistory = errors.New("a serial source has no history without a recording configured")
+
+// loadHistory returns the packets in the time range from the primary source.
+// A serial port only has the history recorded from it.
+func loadHistory(settings models.PluginSettings, tr backend.TimeRange) ([]TelemetryPacket, error) {
+	cfg := settings.SourceConfigs()[0]
+	switch cfg.Type {
+	case "", models.SourceSimulation:
+		return simulateHistory(tr, settings.Simulation)
+	case models.SourceFile:
+		parser, err := NewPacketParser(settings.Parser)
+		if err != nil {
+			return nil, err
+		}
+		return readHistory(cfg.File, parser, tr)
+	case models.SourceSerial:
+		if settings.Recording.File == "" {
+			return nil, errNoHistory
+		}
+		parser, err := NewPacketParser(settings.Parser)
+		if err != nil {
+			return nil, err
+		}
+		packets, err := readHistory(settings.Recording.File, parser, tr)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil // nothing recorded yet
+		}
+		return packets, err
+	default:
+		return nil, fmt.Errorf("unknown source %q", cfg.Type)
+	}
+}
+
+// simulateHistory runs a simulation across the time range on a virtual clock.
+func simulateHistory(tr backend.TimeRange, cfg models.SimulationConfig) ([]TelemetryPacket, error) {
+	start := tr.From
+	if ticks := tr.To.Sub(start) / historyInterval; ticks > maxHistoryTicks {
+		start = tr.To.Add(-maxHistoryTicks * historyInterval)
+	}
+
+	sim, err := newSimulator(start, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var packets []TelemetryPacket
+	for now := start; !now.After(tr.To); now = now.Add(historyInterval) {
+		packets = append(packets, sim.TickAt(now))
+	}
+	return packets, nil
+}
+
+// readHistory parses a capture file, keeping packets inside the time range.
+func readHistory(path string, parser *PacketParser, tr backend.TimeRange) ([]TelemetryPacket, error) {
+	from, to := float64(tr.From.UnixMilli()), float64(tr.To.UnixMilli())
+	return readPackets(path, parser, func(p TelemetryPacket) bool {
+		return p.Timestamp >= from && p.Timestamp <= to
+	})
+}
+
+// readPackets parses a capture file, skipping unparseable lines and packets
+// keep rejects. Columnar captures are recognized by their header.
+func readPackets(path string, parser *PacketParser, keep func(TelemetryPacket) bool) ([]TelemetryPacket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if prefix, _ := r.Peek(len(columnarMagic)); isColumnar(prefix) {
+		packets, err := ReadColumnar(r)
+		if err != nil || keep == nil {
+			return packets, err
+		}
+		return filterPackets(packets, keep), nil
+	}
+
+	var packets []TelemetryPacket
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parsed, _ := parser.ParsePackets(line)
+		for _, packet := range parsed {
+			if keep == nil || keep(packet) {
+				packets = append(packets, packet)
+			}
+		}
+	}
+	return packets, scanner.Err()
+}
+
+// filterPackets returns the packets keep accepts, reusing the slice.
+func filterPackets(packets []TelemetryPacket, keep func(TelemetryPacket) bool) []TelemetryPacket {
+	kept := packets[:0]
+	for _, p := range packets {
+		if keep(p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// BuildAll builds a single frame with one row per packet, leaving out the
+// query's warmup.
+func (b *frameBuilder) BuildAll(packets []TelemetryPacket) *data.Frame {
+	keep := make([]bool, len(packets))
+	for i := b.q.WarmupDiscard.Count(packets); i < len(packets); i++ {
+		keep[i] = true
+	}
+	return b.BuildRows(packets, keep)
+}
+
+// BuildRows builds a single frame with a row for each packet keep marks, or
+// for all of them when keep is nil. Every packet still runs through the
+// builder, so loss, rates and rolling fields are not thrown off by the rows
+// left out.
+func (b *frameBuilder) BuildRows(packets []TelemetryPacket, keep []bool) *data.Frame {
+	var frame *data.Frame
+	for i, packet := range packets {
+		row := b.Build(packet)
+		if keep != nil && !keep[i] {
+			continue
+		}
+		if frame == nil {
+			frame = row
+			continue
+		}
+		frame.AppendRow(row.RowCopy(0)...)
+	}
+	if frame == nil {
+		frame = data.NewFrame("response")
+	}
+	return frame
+}
@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"math"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// LandingETA returns the seconds until touchdown from altitude above the
+// ground at the current vertical speed, or 0 when the rocket is not
+// descending.
+func LandingETA(altitude, verticalSpeed float64) float64 {
+	if verticalSpeed >= 0 || altitude <= 0 {
+		return 0
+	}
+	return altitude / -verticalSpeed
+}
+
+// PredictLanding extrapolates where the rocket touches down eta seconds from
+// now, drifting at groundSpeed (m/s) along heading plus the configured wind.
+func PredictLanding(lat, lon, groundSpeed, heading float64, wind models.WindConfig, eta float64) (float64, float64) {
+	hdg := toRadians(heading)
+	north := groundSpeed * math.Cos(hdg)
+	east := groundSpeed * math.Sin(hdg)
+
+	// Wind direction is where it blows from.
+	downwind := toRadians(wind.Direction + 180)
+	north += wind.Speed * math.Cos(downwind)
+	east += wind.Speed * math.Sin(downwind)
+
+	return offsetLatLon(lat, lon, north*eta, east*eta)
+}
+
+// groundSpeed derives the horizontal speed in m/s between two packets.
+func groundSpeed(prev, curr TelemetryPacket) float64 {
+	dt := (curr.Timestamp - prev.Timestamp) / 1000
+	if dt <= 0 {
+		return 0
+	}
+	return Haversine(prev.GPS.Latitude, prev.GPS.Longitude, curr.GPS.Latitude, curr.GPS.Longitude) / dt
+}
+
+// groundTrack follows the last GPS fix and the ground speed between fixes.
+// Through a GPS dropout it holds both, rather than taking the null island
+// the receiver reports as a position.
+type groundTrack struct {
+	last  *TelemetryPacket
+	speed float64
+}
+
+// Update returns the last good fix and the ground speed in m/s as of packet.
+func (g *groundTrack) Update(packet TelemetryPacket) (GPS, float64) {
+	if gpsLost(packet) {
+		if g.last == nil {
+			return GPS{}, 0
+		}
+		return g.last.GPS, g.speed
+	}
+	if g.last != nil {
+		g.speed = groundSpeed(*g.last, packet)
+	}
+	g.last = &packet
+	return packet.GPS, g.speed
+}
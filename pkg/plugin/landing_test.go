@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestLandingETA(t *testing.T) {
+	for _, tc := range []struct {
+		altitude, vspeed, want float64
+	}{
+		{altitude: 300, vspeed: -10, want: 30},
+		{altitude: 300, vspeed: 5, want: 0}, // still climbing
+		{altitude: 0, vspeed: -10, want: 0},
+	} {
+		if got := LandingETA(tc.altitude, tc.vspeed); got != tc.want {
+			t.Errorf("LandingETA(%v, %v) = %v, want %v", tc.altitude, tc.vspeed, got, tc.want)
+		}
+	}
+}
+
+func TestPredictLanding(t *testing.T) {
+	lat, lon := 37.7749, -122.4194
+
+	// Drifting east at 4 m/s for 30s.
+	pLat, pLon := PredictLanding(lat, lon, 4, 90, models.WindConfig{}, 30)
+	if d := Haversine(lat, lon, pLat, pLon); math.Abs(d-120) > 0.5 {
+		t.Errorf("predicted %.1f m away, want 120", d)
+	}
+	if b := Bearing(lat, lon, pLat, pLon); math.Abs(b-90) > 0.5 {
+		t.Errorf("predicted bearing %.1f, want 90", b)
+	}
+
+	// A 4 m/s wind from the east cancels the drift.
+	pLat, pLon = PredictLanding(lat, lon, 4, 90, models.WindConfig{Speed: 4, Direction: 90}, 30)
+	if d := Haversine(lat, lon, pLat, pLon); d > 0.5 {
+		t.Errorf("predicted %.1f m away, want the current position", d)
+	}
+}
+
+func TestLandingPredictionFields(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"landingETA", "predictedLandingLat", "predictedLandingLon"}
+	b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+	b.Build(TelemetryPacket{Timestamp: 0, Altitude: 210, State: DESCENDING, GPS: GPS{Latitude: 37, Longitude: -122}})
+	frame := b.Build(TelemetryPacket{Timestamp: 1000, Altitude: 200, State: DESCENDING, GPS: GPS{Latitude: 37, Longitude: -122}})
+
+	eta, _ := frame.FieldByName("landingETA")
+	if got := eta.At(0).(float64); got != 20 {
+		t.Errorf("landingETA = %v, want 20", got)
+	}
+	lat, _ := frame.FieldByName("predictedLandingLat")
+	if got := lat.At(0).(float64); got != 37 {
+		t.Errorf("predicted latitude %v for a rocket without drift, want 37", got)
+	}
+}
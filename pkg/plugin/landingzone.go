@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// geoJSONFeature is a GeoJSON feature with a polygon geometry.
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// LandingZone is the search area around a predicted landing spot eta seconds
+// away: a polygon approximating a circle whose radius grows with the time
+// left for the wind to carry the rocket off the prediction. The ring is in
+// GeoJSON order, [longitude, latitude], and closed.
+func LandingZone(lat, lon, eta float64, cfg models.LandingZoneConfig) (ring [][2]float64, radius float64) {
+	radius = cfg.Radius + cfg.WindUncertainty*max(eta, 0)
+	vertices := max(cfg.Vertices, 3)
+	for i := 0; i <= vertices; i++ {
+		bearing := 2 * math.Pi * float64(i%vertices) / float64(vertices)
+		vlat, vlon := offsetLatLon(lat, lon, radius*math.Cos(bearing), radius*math.Sin(bearing))
+		ring = append(ring, [2]float64{vlon, vlat})
+	}
+	return ring, radius
+}
+
+// landingEstimate is where a stream's rocket is predicted to land, eta
+// seconds from now. Outside is set while the rocket is outside the geofence.
+type landingEstimate struct {
+	Lat, Lon, ETA float64
+	Outside       bool
+}
+
+// landingTracker predicts the landing of one stream's packets, from the last
+// GPS fix through a dropout. Each stream keeps its own, so rates are never
+// taken between packets of different sources or simulated rockets.
+type landingTracker struct {
+	settings models.PluginSettings
+	prev     *TelemetryPacket
+	track    groundTrack
+	course   courseTracker
+}
+
+// Update returns the estimate as of packet, false until a GPS fix arrives.
+// Outside of the descent it is the rocket's position.
+func (l *landingTracker) Update(packet TelemetryPacket) (landingEstimate, bool) {
+	var eta float64
+	if packet.State == DESCENDING && l.prev != nil {
+		eta = LandingETA(packet.Altitude, verticalSpeed(*l.prev, packet))
+	}
+	l.prev = &packet
+	heading := l.course.Update(packet)
+	fix, speed := l.track.Update(packet)
+	if l.track.last == nil {
+		return landingEstimate{}, false
+	}
+	lat, lon := PredictLanding(fix.Latitude, fix.Longitude, speed, heading, l.settings.Wind, eta)
+	return landingEstimate{Lat: lat, Lon: lon, ETA: eta, Outside: outsideGeofence(fix, l.settings.Geofence)}, true
+}
+
+// handleLandingZone returns the landing zone of the most recent estimate as a
+// GeoJSON feature, for the recovery map to overlay as a search area. Like the
+// position fields it is withheld while the rocket is outside the geofence.
+func (d *Datasource) handleLandingZone(w http.ResponseWriter, _ *http.Request) {
+	d.mu.Lock()
+	est := d.landing
+	d.mu.Unlock()
+	if est == nil {
+		http.Error(w, "no GPS fix received yet", http.StatusNotFound)
+		return
+	}
+	if est.Outside {
+		http.Error(w, "rocket is outside the geofence", http.StatusNotFound)
+		return
+	}
+
+	lat, lon, eta := est.Lat, est.Lon, est.ETA
+	ring, radius := LandingZone(lat, lon, eta, d.settings.LandingZone)
+
+	writeJSON(w, geoJSONFeature{
+		Type:     "Feature",
+		Geometry: geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}},
+		Properties: map[string]any{
+			"latitude":  lat,
+			"longitude": lon,
+			"radius":    radius,
+			"eta":       eta,
+		},
+	})
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestLandingZone(t *testing.T) {
+	cfg := models.LandingZoneConfig{Radius: 50, WindUncertainty: 2, Vertices: 8}
+	ring, radius := LandingZone(37.7749, -122.4194, 25, cfg)
+	if radius != 100 {
+		t.Errorf("radius = %v, want 100", radius)
+	}
+	if len(ring) != 9 || ring[0] != ring[8] {
+		t.Fatalf("got %d vertices, want a closed ring of 8", len(ring))
+	}
+	for i, v := range ring {
+		if d := Haversine(37.7749, -122.4194, v[1], v[0]); math.Abs(d-100) > 0.1 {
+			t.Errorf("vertex %d is %.2f m from the center, want 100", i, d)
+		}
+	}
+	// The first vertex is due north of the center.
+	if math.Abs(ring[0][0]+122.4194) > 1e-9 || ring[0][1] <= 37.7749 {
+		t.Errorf("first vertex %v is not due north", ring[0])
+	}
+
+	if _, radius := LandingZone(37.7749, -122.4194, 0, cfg); radius != 50 {
+		t.Errorf("on the ground: radius = %v, want 50", radius)
+	}
+}
+
+func TestHandleLandingZone(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	d := &Datasource{settings: settings}
+
+	rec := httptest.NewRecorder()
+	d.handleLandingZone(rec, httptest.NewRequest(http.MethodGet, "/landing/zone", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("without telemetry: got status %d, want 404", rec.Code)
+	}
+
+	// Descending 10 m/s from 200 m: 20 s left.
+	gps := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	stream := &landingTracker{settings: settings}
+	feed(d, stream, TelemetryPacket{Timestamp: 0, Altitude: 210, GPS: gps, State: DESCENDING})
+	feed(d, stream, TelemetryPacket{Timestamp: 1000, Altitude: 200, GPS: gps, State: DESCENDING})
+
+	rec = httptest.NewRecorder()
+	d.handleLandingZone(rec, httptest.NewRequest(http.MethodGet, "/landing/zone", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	var feature struct {
+		Type     string
+		Geometry struct {
+			Type        string
+			Coordinates [][][2]float64
+		}
+		Properties struct {
+			ETA    float64
+			Radius float64
+		}
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&feature); err != nil {
+		t.Fatal(err)
+	}
+	if feature.Type != "Feature" || feature.Geometry.Type != "Polygon" || len(feature.Geometry.Coordinates) != 1 {
+		t.Fatalf("got %+v, want a polygon feature", feature)
+	}
+	if feature.Properties.ETA != 20 || feature.Properties.Radius != 90 {
+		t.Errorf("got eta %v radius %v, want 20 and 90", feature.Properties.ETA, feature.Properties.Radius)
+	}
+}
+
+// feed runs a packet through a stream's landing tracker into the datasource,
+// as RunStream does.
+func feed(d *Datasource, l *landingTracker, packet TelemetryPacket) {
+	if est, ok := l.Update(packet); ok {
+		d.recordLanding(est)
+	}
+}
+
+func TestHandleLandingZoneThroughDropout(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	d := &Datasource{settings: settings}
+	stream := &landingTracker{settings: settings}
+	zone := func() (int, float64, float64) {
+		rec := httptest.NewRecorder()
+		d.handleLandingZone(rec, httptest.NewRequest(http.MethodGet, "/landing/zone", nil))
+		var feature struct {
+			Properties struct{ Latitude, Longitude float64 }
+		}
+		_ = json.NewDecoder(rec.Body).Decode(&feature)
+		return rec.Code, feature.Properties.Latitude, feature.Properties.Longitude
+	}
+
+	feed(d, stream, TelemetryPacket{Timestamp: 0, State: LANDED, GPSNoFix: true})
+	if code, _, _ := zone(); code != http.StatusNotFound {
+		t.Errorf("before a fix: got status %d, want 404", code)
+	}
+	gps := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	feed(d, stream, TelemetryPacket{Timestamp: 1000, State: LANDED, GPS: gps})
+	feed(d, stream, TelemetryPacket{Timestamp: 2000, State: LANDED})
+	if code, lat, lon := zone(); code != http.StatusOK || lat != gps.Latitude || lon != gps.Longitude {
+		t.Errorf("during a dropout: got %d centred on %v, %v, want the last fix", code, lat, lon)
+	}
+}
+
+func TestHandleLandingZoneOutsideGeofence(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	settings.Geofence = models.GeofenceConfig{Latitude: 37.7749, Longitude: -122.4194, Radius: 500}
+	d := &Datasource{settings: settings}
+	stream := &landingTracker{settings: settings}
+
+	feed(d, stream, TelemetryPacket{Timestamp: 0, State: LANDED, GPS: GPS{Latitude: 37.7849, Longitude: -122.4194}})
+	rec := httptest.NewRecorder()
+	d.handleLandingZone(rec, httptest.NewRequest(http.MethodGet, "/landing/zone", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("outside the fence: got status %d, want 404: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,16 @@
+package plugin
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered with the default registry, which the plugin SDK
+// exposes to Grafana.
+var (
+	packetsCoalesced = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "rocket_telemetry",
+		Name:      "packets_coalesced_total",
+		Help:      "Packets dropped by the ingestion rate cap in favor of a newer packet.",
+	})
+)
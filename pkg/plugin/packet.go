@@ -1,11 +1,12 @@
 package plugin
 
 import (
-	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
+	"math"
+	"math/rand"
+	"sync"
 	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
 )
 
 type RocketState int
@@ -16,8 +17,16 @@ const (
 	APEX        RocketState = 2
 	DESCENDING  RocketState = 3
 	CALIBRATION RocketState = 4
+	IGNITION    RocketState = 5 // igniter fired, motor not yet burning
+	UNKNOWN     RocketState = -1
 )
 
+// onPad reports whether state is one before liftoff, from which LAUNCHING
+// starts a new flight.
+func onPad(state RocketState) bool {
+	return state == LANDED || state == CALIBRATION || state == IGNITION
+}
+
 type GPS struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
@@ -34,61 +43,347 @@ type TelemetryPacket struct {
 	GPS            GPS         `json:"gps"`
 	State          RocketState `json:"state"`
 	LoopsPerSecond float64     `json:"loopsPerSecond"`
+	// GPSNoFix and DeadReckoned flag a receiver without a satellite fix, and
+	// one estimating its position while it has none.
+	GPSNoFix     bool `json:"gpsNoFix,omitempty"`
+	DeadReckoned bool `json:"deadReckoned,omitempty"`
+	// Velocity is the vertical speed in m/s reported by the avionics, when
+	// they report one.
+	Velocity *float64 `json:"velocity,omitempty"`
+	// Armed is set by a simulation armed through the /sim endpoint.
+	Armed bool `json:"armed,omitempty"`
+	// Seed is the random seed of the simulation that generated the packet.
+	Seed int64 `json:"seed,omitempty"`
+	// SensorFailed marks a packet with a simulated dead sensor.
+	SensorFailed bool `json:"sensorFailed,omitempty"`
+	// Source names the configured source that delivered the packet.
+	Source string `json:"source,omitempty"`
+	// Receivers holds the RSSI of the latest packet from each source still
+	// delivering fresh data, by source name, when failing over between them.
+	Receivers map[string]int `json:"receivers,omitempty"`
+	// GForceClamped marks a packet whose g-force was clamped to the parser's
+	// maximum.
+	GForceClamped bool `json:"gforceClamped,omitempty"`
+	// DetectedState is the state inferred by the state detector, when the
+	// simulation runs one.
+	DetectedState *RocketState `json:"detectedState,omitempty"`
 }
 
+// RocketSimulation is a simple physics model of a flight. It is safe for
+// concurrent use: control methods may be called while a stream is ticking.
 type RocketSimulation struct {
+	mu        sync.Mutex
+	cfg       models.SimulationConfig
+	rng       *rand.Rand
+	noise     *rand.Rand // separate from rng so idle noise leaves flights as seeded
+	seed      int64
 	startTime time.Time
+	lastTick  time.Time
 	state     RocketState
 	altitude  float64
-	velocity  float64
+	velocity  float64 // vertical, m/s
+	hVelocity float64 // horizontal along the launch azimuth, m/s
+	pitch     float64
 	lat       float64
 	lon       float64
+	roll      float64 // degrees in [0, 360)
+	gps       gpsReceiver
+	accel     accelerometer
+
+	liftoff      time.Time
+	staged       bool // the second stage has fired
+	descentStart time.Time
+
+	armed        bool
+	abortPlanned bool // this countdown will scrub
+	hangFire     bool // this countdown's motor lights late or not at all
+	holding      bool // scrubbed with no retry
+	paused       bool
+	last         TelemetryPacket
 }
 
-func NewRocketSimulation() *RocketSimulation {
-	return &RocketSimulation{
-		startTime: time.Now(),
+func NewRocketSimulation(cfg models.SimulationConfig) *RocketSimulation {
+	return NewRocketSimulationAt(time.Now(), cfg)
+}
+
+// NewRocketSimulationAt creates a simulation whose clock starts at start, for
+// generating flights at times other than now.
+func NewRocketSimulationAt(start time.Time, cfg models.SimulationConfig) *RocketSimulation {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = start.UnixNano()
+	}
+	s := &RocketSimulation{
+		cfg:       cfg,
+		rng:       rand.New(rand.NewSource(seed)),
+		noise:     rand.New(rand.NewSource(seed + 1)),
+		seed:      seed,
+		startTime: start,
 		state:     LANDED,
 		altitude:  0,
 		velocity:  0,
-		lat:       37.7749, // Default start (SF)
-		lon:       -122.4194,
+		pitch:     90,
 	}
+	s.lat, s.lon = s.pad()
+	s.planCountdown()
+	return s
+}
+
+// planCountdown decides whether the upcoming countdown will be scrubbed.
+func (s *RocketSimulation) planCountdown() {
+	s.abortPlanned = s.cfg.AbortProbability > 0 && s.rng.Float64() < s.cfg.AbortProbability
+	s.hangFire = s.cfg.HangFireProbability > 0 && s.rng.Float64() < s.cfg.HangFireProbability
+}
+
+// ignite lights the motor and leaves the rail.
+func (s *RocketSimulation) ignite(now time.Time) {
+	s.state = LAUNCHING
+	s.liftoff, s.staged = now, false
+	s.boost(s.cfg.LaunchVelocity)
+}
+
+// boost adds dv m/s along the launch angle.
+func (s *RocketSimulation) boost(dv float64) {
+	angle := toRadians(s.cfg.LaunchAngle)
+	s.velocity += dv * math.Cos(angle)
+	s.hVelocity += dv * math.Sin(angle)
+}
+
+// recycle puts the rocket back on the pad for the next countdown.
+func (s *RocketSimulation) recycle(now time.Time) {
+	s.state = LANDED
+	s.armed = false
+	s.startTime = now
+	s.planCountdown()
+}
+
+// pad is the position of the launch pad, offset from the default launch site
+// (SF) by PadNorth and PadEast.
+func (s *RocketSimulation) pad() (float64, float64) {
+	return offsetLatLon(37.7749, -122.4194, s.cfg.PadNorth, s.cfg.PadEast)
+}
+
+// moveDownrange displaces the rocket along the launch azimuth.
+func (s *RocketSimulation) moveDownrange(d float64) {
+	az := toRadians(s.cfg.LaunchAzimuth)
+	s.lat, s.lon = offsetLatLon(s.lat, s.lon, d*math.Cos(az), d*math.Sin(az))
+}
+
+// yaw is the heading of a tilted launch, or 0 for a vertical one.
+func (s *RocketSimulation) yaw() float64 {
+	if s.cfg.LaunchAngle == 0 {
+		return 0
+	}
+	return wrapDegrees(s.cfg.LaunchAzimuth)
+}
+
+// clock is the simulation's current time: that of the last tick, or its start
+// before the first one.
+func (s *RocketSimulation) clock() time.Time {
+	if s.lastTick.IsZero() {
+		return s.startTime
+	}
+	return s.lastTick
+}
+
+// Arm releases a simulation configured with RequireArming to start its
+// countdown. After an abort it restarts the countdown, planning whether it
+// scrubs afresh.
+func (s *RocketSimulation) Arm() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.armed = true
+	if s.holding {
+		s.holding = false
+		s.startTime = s.clock()
+		s.planCountdown()
+	}
+}
+
+// Launch ignites the motor straight away when the rocket is on the pad,
+// skipping any countdown, arming or hang-fire.
+func (s *RocketSimulation) Launch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == LANDED || s.state == IGNITION {
+		s.holding = false
+		s.ignite(s.clock())
+	}
+}
+
+// Abort scrubs a countdown, holding on the pad disarmed until armed again.
+// In flight it terminates the flight: the parachute deploys immediately.
+func (s *RocketSimulation) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.state {
+	case LANDED, IGNITION:
+		s.state = LANDED
+		s.armed = false
+		s.holding = true
+	case LAUNCHING, APEX:
+		s.velocity = min(s.velocity, 0)
+		s.state = DESCENDING
+		s.descentStart = s.clock()
+	}
+}
+
+// Pause freezes the simulation: ticks repeat the last packet and all timers
+// stop until Resume.
+func (s *RocketSimulation) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+}
+
+func (s *RocketSimulation) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+}
+
+// Armed reports whether the simulation has been armed for the current
+// countdown.
+func (s *RocketSimulation) Armed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.armed
+}
+
+// Reset puts the rocket back on the pad at the launch site, disarmed, with
+// the countdown restarting at now.
+func (s *RocketSimulation) Reset(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.altitude, s.velocity, s.hVelocity = 0, 0, 0
+	s.pitch, s.roll = 90, 0
+	s.lat, s.lon = s.pad()
+	s.holding = false
+	s.recycle(now)
+}
+
+// Config returns the simulation config with the effective seed filled in,
+// ready to be saved to settings to replay the same flight.
+func (s *RocketSimulation) Config() models.SimulationConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg := s.cfg
+	cfg.Seed = s.seed
+	return cfg
+}
+
+// Configure replaces the simulation config. It takes effect from the next
+// tick; a countdown already planned keeps its abort and hang-fire outcome.
+func (s *RocketSimulation) Configure(cfg models.SimulationConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
 }
 
 func (s *RocketSimulation) Tick() TelemetryPacket {
-	dt := 0.5 // Time step in seconds (approximate if called every 500ms)
-	now := time.Now()
+	return s.TickAt(time.Now())
+}
+
+// TickAt advances the simulation by one step taken at now.
+func (s *RocketSimulation) TickAt(now time.Time) TelemetryPacket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Step by the time since the previous tick, which varies with an
+	// adaptive cadence; the first tick assumes the default 500ms.
+	dt := 0.5
+	if !s.lastTick.IsZero() && now.After(s.lastTick) {
+		dt = now.Sub(s.lastTick).Seconds()
+	}
+	s.lastTick = now
+
+	if s.paused {
+		// Shift the clocks so timers resume where they left off.
+		shift := time.Duration(dt * float64(time.Second))
+		s.startTime = s.startTime.Add(shift)
+		s.liftoff = s.liftoff.Add(shift)
+		s.descentStart = s.descentStart.Add(shift)
+		s.gps.dropoutUntil = s.gps.dropoutUntil.Add(shift)
+		p := s.last
+		p.Timestamp = float64(now.UnixMilli())
+		return p
+	}
 	elapsed := now.Sub(s.startTime).Seconds()
 
 	// Simple state machine for simulation
 	switch s.state {
 	case LANDED:
-		if elapsed > 5 { // Launch after 5 seconds
-			s.state = LAUNCHING
-			s.velocity = 150
+		if s.holding {
+			break
+		}
+		if s.cfg.RequireArming && !s.armed {
+			s.startTime = now // the countdown starts once armed
+			break
+		}
+		if s.abortPlanned && elapsed > s.cfg.LaunchDelay-s.cfg.AbortAt {
+			// Scrub the launch and hold on the pad.
+			if s.cfg.RetryDelay > 0 {
+				s.startTime = now.Add(time.Duration(s.cfg.RetryDelay * float64(time.Second)))
+				s.planCountdown()
+			} else {
+				s.holding = true
+			}
+			break
+		}
+		if elapsed > s.cfg.LaunchDelay {
+			if s.hangFire {
+				s.state = IGNITION
+				s.startTime = now // time the hang from the igniter firing
+				break
+			}
+			s.ignite(now)
+		}
+	case IGNITION:
+		lights := s.cfg.HangFireDelay < s.cfg.HangFireTimeout
+		if lights && elapsed >= s.cfg.HangFireDelay {
+			s.ignite(now)
+		} else if elapsed >= s.cfg.HangFireTimeout {
+			// Misfire: safe the pad and recycle.
+			s.recycle(now)
 		}
 	case LAUNCHING:
+		if s.cfg.SecondStageVelocity > 0 && !s.staged && now.Sub(s.liftoff).Seconds() >= s.cfg.SecondStageDelay {
+			s.boost(s.cfg.SecondStageVelocity)
+			s.staged = true
+		}
 		s.altitude += s.velocity * dt
 		s.velocity -= 9.8 * dt // Gravity
+		s.moveDownrange(s.hVelocity * dt)
+		if s.hVelocity != 0 {
+			// The airframe follows the velocity vector over the top.
+			s.pitch = toDegrees(math.Atan2(s.velocity, s.hVelocity))
+		}
 		if s.velocity <= 0 {
 			s.state = APEX
 		}
 	case APEX:
 		s.state = DESCENDING
+		s.descentStart = now
 	case DESCENDING:
+		terminal := -s.cfg.DescentRate // Terminal velocity with parachute
+		if s.cfg.DescentSpeedup > 0 {
+			terminal *= s.cfg.DescentSpeedup
+		}
 		s.velocity -= 9.8 * dt
-		if s.velocity < -10 { // Terminal velocity with parachute
-			s.velocity = -10
+		if s.velocity < terminal {
+			s.velocity = terminal
 		}
 		s.altitude += s.velocity * dt
+		if s.cfg.MaxDescentTime > 0 && now.Sub(s.descentStart).Seconds() >= s.cfg.MaxDescentTime {
+			s.altitude = 0 // cut the descent short and touch down
+		}
 		if s.altitude <= 0 {
 			s.altitude = 0
 			s.velocity = 0
-			s.state = LANDED
-			s.startTime = now
-			s.lat = 37.7749
-			s.lon = -122.4194
+			s.hVelocity = 0
+			s.pitch = 90
+			s.recycle(now)
+			s.lat, s.lon = s.pad()
 		}
 	}
 
@@ -96,98 +391,61 @@ func (s *RocketSimulation) Tick() TelemetryPacket {
 	if s.state == LAUNCHING || s.state == APEX || s.state == DESCENDING {
 		s.lat += 0.0001 * dt
 		s.lon += 0.0001 * dt
+		s.roll = wrapDegrees(s.roll + s.cfg.SpinRate*dt)
 	}
 
-	return TelemetryPacket{
-		Signal:    -50,
-		Timestamp: float64(now.UnixMilli()),
-		Pitch:     90, // Vertical
-		Roll:      0,
-		Yaw:       0,
-		GForce:    1.0 + (s.velocity/9.8)/10.0, // Rough approx
-		Altitude:  s.altitude,
-		GPS: GPS{
-			Latitude:  s.lat,
-			Longitude: s.lon,
-		},
+	fix, hasFix, deadReckoned := s.reportGPS(now, GPS{Latitude: s.lat, Longitude: s.lon}, dt)
+	velocity := s.velocity
+	vAccel, hAccel := s.accel.Update(now, s.velocity, s.hVelocity)
+
+	s.last = TelemetryPacket{
+		Signal:         -50,
+		Timestamp:      float64(now.UnixMilli()),
+		Pitch:          s.pitch,
+		Roll:           s.roll,
+		Yaw:            s.yaw(),
+		GForce:         GForce(s.cfg.GForceMethod, s.velocity, hAccel, vAccel),
+		Altitude:       s.altitude,
+		GPS:            fix,
 		State:          s.state,
 		LoopsPerSecond: 10,
+		GPSNoFix:       !hasFix,
+		DeadReckoned:   deadReckoned,
+		Armed:          s.armed,
+		Seed:           s.seed,
+		Velocity:       &velocity,
 	}
-}
-
-func ParsePacket(packetString string) (*TelemetryPacket, error) {
-	// Check if the message has the "Received - RSSI: X, Message: " format
-	message := packetString
-	rssi := -50 // Default
-
-	// Regex to match RSSI and Message
-	// Matches "RSSI: -89, Message: 1234,..."
-	re := regexp.MustCompile(`RSSI:\s*(-?\d+),\s*Message:\s*(.+)`)
-	matches := re.FindStringSubmatch(packetString)
-	if len(matches) == 3 {
-		if val, err := strconv.Atoi(matches[1]); err == nil {
-			rssi = val
-		}
-		message = strings.TrimSpace(matches[2])
-	}
-
-	parts := strings.Split(message, ",")
-	for i := range parts {
-		parts[i] = strings.TrimSpace(parts[i])
+	if s.state == LANDED && s.cfg.IdleNoise.Enabled {
+		s.addIdleNoise(&s.last)
 	}
+	return s.last
+}
 
-	// Radio packet format: timestamp,pitch,roll,yaw,gforce,altitude,lat,lon,state,loops
-	if len(parts) != 10 {
-		return nil, fmt.Errorf("invalid packet length: expected 10 parts, got %d", len(parts))
-	}
+// addIdleNoise jitters the sensors of a packet from the pad.
+func (s *RocketSimulation) addIdleNoise(p *TelemetryPacket) {
+	n := s.cfg.IdleNoise
+	jitter := func(sd float64) float64 { return s.noise.NormFloat64() * sd }
+	p.Altitude += jitter(n.Altitude)
+	p.Pitch += jitter(n.Attitude)
+	p.Roll = wrapDegrees(p.Roll + jitter(n.Attitude))
+	p.Yaw = wrapDegrees(p.Yaw + jitter(n.Attitude))
+	p.GForce += jitter(n.GForce)
+	p.Signal += int(math.Round(jitter(n.Signal)))
+}
 
-	// Helper to parse float
-	parseFloat := func(s string) float64 {
-		val, _ := strconv.ParseFloat(s, 64)
-		return val
-	}
+// ParsePacket parses a radio packet using the default state names.
+func ParsePacket(packetString string) (*TelemetryPacket, error) {
+	return defaultParser.Parse(packetString)
+}
 
-	timestamp := parseFloat(parts[0])
-	pitch := parseFloat(parts[1])
-	roll := parseFloat(parts[2])
-	yaw := parseFloat(parts[3])
-	gforce := parseFloat(parts[4])
-	altitude := parseFloat(parts[5])
-	lat := parseFloat(parts[6])
-	lon := parseFloat(parts[7])
-
-	stateString := strings.ToUpper(parts[8])
-	var state RocketState
-	switch stateString {
-	case "LANDED":
-		state = LANDED
-	case "LAUNCHING":
-		state = LAUNCHING
-	case "APEX":
-		state = APEX
-	case "DESCENDING":
-		state = DESCENDING
-	case "CALIBRATION":
-		state = CALIBRATION
-	default:
-		state = LANDED
-	}
+// ParsePackets parses a line of one or more "|" delimited radio records using
+// the default state names.
+func ParsePackets(line string) ([]TelemetryPacket, []error) {
+	return defaultParser.ParsePackets(line)
+}
 
-	loops := parseFloat(parts[9])
-
-	return &TelemetryPacket{
-		Signal:    rssi,
-		Timestamp: timestamp,
-		Pitch:     pitch,
-		Roll:      roll,
-		Yaw:       yaw,
-		GForce:    gforce,
-		Altitude:  altitude,
-		GPS: GPS{
-			Latitude:  lat,
-			Longitude: lon,
-		},
-		State:          state,
-		LoopsPerSecond: loops,
-	}, nil
+// ParseAny parses a line in any of the supported telemetry formats using the
+// default state names.
+func ParseAny(line string) (*TelemetryPacket, error) {
+	return defaultParser.ParseAny(line)
 }
@@ -0,0 +1,403 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// runSimulation ticks a simulation every 500ms for d and returns the packets.
+func runSimulation(sim *RocketSimulation, start time.Time, d time.Duration) []TelemetryPacket {
+	var packets []TelemetryPacket
+	for now := start; now.Sub(start) <= d; now = now.Add(500 * time.Millisecond) {
+		packets = append(packets, sim.TickAt(now))
+	}
+	return packets
+}
+
+func launched(packets []TelemetryPacket) bool {
+	for _, p := range packets {
+		if p.State == LAUNCHING {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSimulationAbortHolds(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.AbortProbability = 1
+
+	start := time.UnixMilli(0)
+	sim := NewRocketSimulationAt(start, cfg)
+
+	if launched(runSimulation(sim, start, time.Minute)) {
+		t.Fatal("scrubbed countdown launched anyway")
+	}
+}
+
+func TestSimulationAbortRetries(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.AbortProbability = 0.5
+	cfg.RetryDelay = 10
+
+	start := time.UnixMilli(0)
+	sim := NewRocketSimulationAt(start, cfg)
+	sim.abortPlanned = true // force the first countdown to scrub
+
+	packets := runSimulation(sim, start, 20*time.Second)
+	for _, p := range packets[:20] { // first 10s covers the scrubbed countdown
+		if p.State != LANDED {
+			t.Fatalf("left the pad during scrubbed countdown at %v", p.Timestamp)
+		}
+	}
+
+	sim.abortPlanned = false
+	if !launched(runSimulation(sim, start.Add(20*time.Second), time.Minute)) {
+		t.Fatal("recycled countdown never launched")
+	}
+}
+
+func TestSimulationSpin(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.SpinRate = 300
+
+	start := time.UnixMilli(0)
+	packets := runSimulation(NewRocketSimulationAt(start, cfg), start, 20*time.Second)
+
+	wrapped := false
+	for i, p := range packets {
+		if p.Roll < 0 || p.Roll >= 360 {
+			t.Fatalf("roll %v out of [0, 360)", p.Roll)
+		}
+		if p.State == LANDED && p.Roll != 0 {
+			t.Fatalf("rolled on the pad at packet %d", i)
+		}
+		if i > 0 && p.Roll < packets[i-1].Roll {
+			wrapped = true
+		}
+	}
+	if !wrapped {
+		t.Fatal("roll never wrapped at 360")
+	}
+}
+
+func TestSimulationLaunchAngle(t *testing.T) {
+	start := time.UnixMilli(0)
+	apex := func(cfg models.SimulationConfig) (TelemetryPacket, []TelemetryPacket) {
+		packets := runSimulation(NewRocketSimulationAt(start, cfg), start, time.Minute)
+		for _, p := range packets {
+			if p.State == APEX {
+				return p, packets
+			}
+		}
+		t.Fatal("never reached apex")
+		return TelemetryPacket{}, nil
+	}
+
+	cfg := models.DefaultPluginSettings().Simulation
+	vertical, _ := apex(cfg)
+
+	cfg.LaunchAngle = 10
+	cfg.LaunchAzimuth = 90
+	tilted, packets := apex(cfg)
+
+	if vertical.Pitch != 90 {
+		t.Errorf("vertical flight pitch %v, want 90", vertical.Pitch)
+	}
+	if tilted.GPS.Longitude <= vertical.GPS.Longitude {
+		t.Errorf("eastward launch did not move downrange: %v <= %v", tilted.GPS.Longitude, vertical.GPS.Longitude)
+	}
+	if tilted.Altitude >= vertical.Altitude {
+		t.Errorf("tilted apogee %v not below vertical apogee %v", tilted.Altitude, vertical.Altitude)
+	}
+	if tilted.Yaw != 90 {
+		t.Errorf("got yaw %v, want the launch azimuth", tilted.Yaw)
+	}
+
+	prev := 90.0
+	for _, p := range packets {
+		if p.State != LAUNCHING {
+			continue
+		}
+		if p.Pitch > prev {
+			t.Fatalf("pitch increased during boost: %v > %v", p.Pitch, prev)
+		}
+		prev = p.Pitch
+	}
+	if prev > 10 {
+		t.Errorf("pitch at end of boost %v, want near horizontal", prev)
+	}
+}
+
+func TestSimulationRequiresArming(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.RequireArming = true
+
+	start := time.UnixMilli(0)
+	sim := NewRocketSimulationAt(start, cfg)
+
+	if launched(runSimulation(sim, start, time.Minute)) {
+		t.Fatal("launched without being armed")
+	}
+
+	armedAt := start.Add(time.Minute)
+	sim.Arm()
+	packets := runSimulation(sim, armedAt, time.Duration(cfg.LaunchDelay*float64(time.Second))-time.Second)
+	if launched(packets) {
+		t.Fatal("launched before the countdown finished")
+	}
+	if !launched(runSimulation(sim, armedAt.Add(5*time.Second), 10*time.Second)) {
+		t.Fatal("never launched after arming")
+	}
+}
+
+func TestSimulationLaunchBeforeFirstTick(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.LaunchAngle = 0
+	cfg.SecondStageVelocity = 100
+	cfg.SecondStageDelay = 3
+	cfg.MaxDescentTime = 5
+
+	start := time.UnixMilli(1_000_000)
+	sim := NewRocketSimulationAt(start, cfg)
+	sim.Launch()
+	// Liftoff is at the simulation's start, so neither the second stage nor
+	// the descent timer is due on the first tick.
+	packet := sim.TickAt(start.Add(500 * time.Millisecond))
+	if packet.State != LAUNCHING || *packet.Velocity > cfg.LaunchVelocity {
+		t.Errorf("first tick: state %v at %v m/s, want climbing on the first stage alone", packet.State, *packet.Velocity)
+	}
+	if p := sim.TickAt(start.Add(3500 * time.Millisecond)); *p.Velocity <= *packet.Velocity {
+		t.Errorf("second stage never fired: %v m/s", *p.Velocity)
+	}
+}
+
+func TestSimulationGPSDropout(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.GPSDropoutRate = 0.2
+	cfg.GPSDropoutDuration = 3
+
+	start := time.UnixMilli(0)
+	for _, dr := range []bool{false, true} {
+		cfg.DeadReckoning = dr
+		packets := runSimulation(NewRocketSimulationAt(start, cfg), start, time.Minute)
+
+		dropped := 0
+		for i, p := range packets {
+			if !p.GPSNoFix {
+				if p.DeadReckoned {
+					t.Fatalf("packet %d dead reckoned with a fix", i)
+				}
+				continue
+			}
+			dropped++
+			if p.DeadReckoned != dr {
+				t.Fatalf("packet %d: deadReckoned = %v, want %v", i, p.DeadReckoned, dr)
+			}
+			zero := p.GPS == GPS{}
+			if zero == dr {
+				t.Fatalf("packet %d: position %+v with dead reckoning %v", i, p.GPS, dr)
+			}
+		}
+		if dropped == 0 {
+			t.Fatalf("dead reckoning %v: the fix never dropped", dr)
+		}
+	}
+}
+
+func TestSimulationHangFire(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.HangFireProbability = 1
+	cfg.HangFireDelay = 4
+
+	start := time.UnixMilli(0)
+	packets := runSimulation(NewRocketSimulationAt(start, cfg), start, 15*time.Second)
+
+	var hang, first int
+	for i, p := range packets {
+		if p.State == IGNITION {
+			hang++
+		}
+		if p.State == LAUNCHING && first == 0 {
+			first = i
+		}
+	}
+	if hang < 7 || hang > 9 {
+		t.Errorf("held in IGNITION for %d ticks, want about 4s worth", hang)
+	}
+	if first == 0 || packets[first-1].State != IGNITION {
+		t.Fatal("motor did not light after the hang")
+	}
+}
+
+func TestSimulationNoIgnition(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.HangFireProbability = 1
+	cfg.HangFireDelay = 10
+	cfg.HangFireTimeout = 10
+
+	start := time.UnixMilli(0)
+	packets := runSimulation(NewRocketSimulationAt(start, cfg), start, 20*time.Second)
+
+	if launched(packets) {
+		t.Fatal("misfired motor launched")
+	}
+	last := packets[len(packets)-1]
+	if last.State != LANDED {
+		t.Fatalf("state %v after the misfire timeout, want LANDED", last.State)
+	}
+}
+
+func TestSimulationGPSBias(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	start := time.UnixMilli(0)
+	truth := runSimulation(NewRocketSimulationAt(start, cfg), start, time.Minute)
+
+	cfg.GPSBias = 50
+	cfg.GPSBiasBearing = 135
+	biased := runSimulation(NewRocketSimulationAt(start, cfg), start, time.Minute)
+
+	for i := range truth {
+		a, b := truth[i].GPS, biased[i].GPS
+		if d := Haversine(a.Latitude, a.Longitude, b.Latitude, b.Longitude); math.Abs(d-50) > 0.1 {
+			t.Fatalf("packet %d: biased %.2f m from the truth, want 50", i, d)
+		}
+		if brg := Bearing(a.Latitude, a.Longitude, b.Latitude, b.Longitude); math.Abs(brg-135) > 0.1 {
+			t.Fatalf("packet %d: bias bearing %.2f, want 135", i, brg)
+		}
+	}
+}
+
+// descentTime returns how long the first descent took, from apogee to landing.
+func descentTime(packets []TelemetryPacket) float64 {
+	var apex float64
+	for i, p := range packets {
+		if p.State == APEX {
+			apex = p.Timestamp
+		}
+		if apex > 0 && p.State == LANDED && packets[i-1].State == DESCENDING {
+			return (p.Timestamp - apex) / 1000
+		}
+	}
+	return 0
+}
+
+func TestSimulationShortenedDescent(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	start := time.UnixMilli(0)
+	full := descentTime(runSimulation(NewRocketSimulationAt(start, cfg), start, 5*time.Minute))
+	if full == 0 {
+		t.Fatal("default flight never landed")
+	}
+
+	cfg.DescentSpeedup = 4
+	if fast := descentTime(runSimulation(NewRocketSimulationAt(start, cfg), start, 5*time.Minute)); fast == 0 || fast > full/3 {
+		t.Errorf("sped up descent took %vs, full descent %vs", fast, full)
+	}
+
+	cfg.DescentSpeedup = 1
+	cfg.MaxDescentTime = 20
+	if limited := descentTime(runSimulation(NewRocketSimulationAt(start, cfg), start, 5*time.Minute)); limited < 19 || limited > 21 {
+		t.Errorf("time limited descent took %vs, want 20s", limited)
+	}
+}
+
+func TestSimulationSeedReplays(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.GPSDropoutRate = 0.2
+	cfg.GPSDropoutDuration = 2
+	cfg.AbortProbability = 0.5
+	cfg.RetryDelay = 5
+
+	first := NewRocketSimulationAt(time.UnixMilli(1_000_000), cfg)
+	cfg.Seed = first.Config().Seed
+	replay := NewRocketSimulationAt(time.UnixMilli(9_000_000), cfg)
+
+	a := runSimulation(first, time.UnixMilli(1_000_000), 3*time.Minute)
+	b := runSimulation(replay, time.UnixMilli(9_000_000), 3*time.Minute)
+	for i := range a {
+		if a[i].State != b[i].State || a[i].Altitude != b[i].Altitude || a[i].GPSNoFix != b[i].GPSNoFix {
+			t.Fatalf("tick %d differs: %+v vs %+v", i, a[i], b[i])
+		}
+	}
+	if b[0].Seed != cfg.Seed {
+		t.Errorf("packet seed = %d, want %d", b[0].Seed, cfg.Seed)
+	}
+
+	frame := newFrameBuilder(defaultQuery(), models.DefaultPluginSettings()).Build(b[0])
+	if frame.Meta == nil || frame.Meta.Custom.(map[string]any)["seed"] != cfg.Seed {
+		t.Errorf("frame meta %+v does not carry the seed", frame.Meta)
+	}
+}
+
+func TestSimulationIdleNoise(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.Seed = 7
+	cfg.RequireArming = true
+	quiet := runSimulation(NewRocketSimulationAt(time.UnixMilli(0), cfg), time.UnixMilli(0), 10*time.Second)
+
+	cfg.IdleNoise.Enabled = true
+	noisy := runSimulation(NewRocketSimulationAt(time.UnixMilli(0), cfg), time.UnixMilli(0), 10*time.Second)
+
+	moved := false
+	for i, p := range noisy {
+		if p.State != LANDED {
+			t.Fatalf("tick %d: noise changed the state to %v", i, p.State)
+		}
+		if math.Abs(p.Altitude) > 5*cfg.IdleNoise.Altitude {
+			t.Errorf("tick %d: altitude %v is more than noise", i, p.Altitude)
+		}
+		if p.Altitude != quiet[i].Altitude || p.Signal != quiet[i].Signal {
+			moved = true
+		}
+	}
+	if !moved {
+		t.Error("idle telemetry is flat with noise enabled")
+	}
+	for i, p := range quiet {
+		if p.Altitude != 0 || p.Signal != -50 {
+			t.Fatalf("tick %d: got altitude %v signal %d with noise off", i, p.Altitude, p.Signal)
+		}
+	}
+}
+
+func TestSimulationPresetsFly(t *testing.T) {
+	for name, apogee := range map[string]float64{"estes-alpha": 300, "high-power-l1": 1100, "two-stage": 2500} {
+		cfg, ok := models.SimulationPresetConfig(name)
+		if !ok {
+			t.Fatalf("missing preset %q", name)
+		}
+		start := time.UnixMilli(0)
+		var top float64
+		for _, p := range runSimulation(NewRocketSimulationAt(start, cfg), start, 3*time.Minute) {
+			top = max(top, p.Altitude)
+		}
+		if top < apogee*0.85 || top > apogee*1.15 {
+			t.Errorf("%s: apogee %.0f m, want about %.0f", name, top, apogee)
+		}
+	}
+}
+
+func TestSimulationPresetOverrides(t *testing.T) {
+	settings, err := models.LoadPluginSettings(backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{"simulation":{"preset":"estes-alpha","descentRate":2}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := settings.Simulation.LaunchVelocity; got != 77 {
+		t.Errorf("launch velocity %v, want the preset's 77", got)
+	}
+	if got := settings.Simulation.DescentRate; got != 2 {
+		t.Errorf("descent rate %v, want the explicit 2", got)
+	}
+
+	_, err = models.LoadPluginSettings(backend.DataSourceInstanceSettings{JSONData: []byte(`{"simulation":{"preset":"saturn-v"}}`)})
+	if err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
@@ -0,0 +1,49 @@
+package plugin
+
+import (
+	"math"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// lossTracker estimates packet loss from gaps in packet timestamps: a gap of
+// n expected intervals means n-1 packets went missing. The interval after a
+// packet is the cadence of its flight phase, or the expected interval where
+// the cadence is left at the default. Loss is reported over the last window
+// received packets and starts over with each new flight.
+type lossTracker struct {
+	interval float64 // expected ms between packets
+	cadence  models.CadenceConfig
+	missed   *rollingWindow // packets missed before each received packet
+	prev     *TelemetryPacket
+}
+
+func newLossTracker(window int, expectedInterval float64, cadence models.CadenceConfig) *lossTracker {
+	return &lossTracker{interval: expectedInterval * 1000, cadence: cadence, missed: newRollingWindow(window)}
+}
+
+// Update records a received packet and returns the loss percentage over the
+// window.
+func (l *lossTracker) Update(p TelemetryPacket) float64 {
+	if l.prev != nil && l.prev.State == LANDED && p.State != LANDED && p.State != CALIBRATION && p.State != UNKNOWN {
+		l.missed.Reset()
+	}
+
+	missed := 0
+	if l.prev != nil {
+		interval := l.interval
+		if seconds := phaseCadence(l.cadence, l.prev.State); seconds > 0 {
+			interval = seconds * 1000
+		}
+		if interval > 0 {
+			gap := p.Timestamp - l.prev.Timestamp
+			missed = max(int(math.Round(gap/interval))-1, 0)
+		}
+	}
+	l.prev = &p
+
+	l.missed.Add(float64(missed))
+
+	total := l.missed.Sum()
+	return 100 * total / (total + float64(l.missed.Len()))
+}
@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestLossTracker(t *testing.T) {
+	l := newLossTracker(4, 0.5, models.CadenceConfig{})
+
+	var got float64
+	for _, ts := range []float64{0, 500, 1000, 2500} { // two lost before 2500
+		got = l.Update(TelemetryPacket{Timestamp: ts, State: LANDED})
+	}
+	if want := 100 * 2.0 / 6; math.Abs(got-want) > 1e-9 {
+		t.Errorf("loss %v%%, want %v%%", got, want)
+	}
+
+	// The gap rolls out of the window after four more clean packets.
+	for _, ts := range []float64{3000, 3500, 4000, 4500} {
+		got = l.Update(TelemetryPacket{Timestamp: ts, State: LANDED})
+	}
+	if got != 0 {
+		t.Errorf("loss %v%% after the gap left the window, want 0", got)
+	}
+
+	// A new flight starts with a clean slate.
+	l.Update(TelemetryPacket{Timestamp: 9000, State: LANDED})
+	if got := l.Update(TelemetryPacket{Timestamp: 9500, State: LAUNCHING}); got != 0 {
+		t.Errorf("loss %v%% at liftoff, want the window reset", got)
+	}
+}
+
+func TestLossTrackerFollowsCadence(t *testing.T) {
+	l := newLossTracker(4, 0.5, models.CadenceConfig{Pad: 2})
+
+	var got float64
+	for _, ts := range []float64{0, 2000, 4000, 6000} {
+		got = l.Update(TelemetryPacket{Timestamp: ts, State: LANDED})
+	}
+	if got != 0 {
+		t.Errorf("loss %v%% at the 2s pad cadence, want 0", got)
+	}
+
+	// The ascent is left at the 500ms default: one lost before 8000.
+	for _, ts := range []float64{6500, 7000, 8000} {
+		got = l.Update(TelemetryPacket{Timestamp: ts, State: LAUNCHING})
+	}
+	if want := 100 * 1.0 / 4; math.Abs(got-want) > 1e-9 {
+		t.Errorf("loss %v%% in ascent, want %v%%", got, want)
+	}
+}
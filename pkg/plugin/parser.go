@@ -0,0 +1,309 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+var stateNames = map[string]RocketState{
+	"LANDED":      LANDED,
+	"LAUNCHING":   LAUNCHING,
+	"APEX":        APEX,
+	"DESCENDING":  DESCENDING,
+	"CALIBRATION": CALIBRATION,
+	"IGNITION":    IGNITION,
+	"UNKNOWN":     UNKNOWN,
+}
+
+// stateName is the radio name of a state.
+func stateName(state RocketState) string {
+	for name, s := range stateNames {
+		if s == state {
+			return name
+		}
+	}
+	return "UNKNOWN"
+}
+
+var defaultParser = mustParser(models.DefaultPluginSettings().Parser)
+
+// PacketParseError reports a telemetry line that could not be parsed.
+type PacketParseError struct {
+	Line   string `json:"line"`
+	Reason string `json:"reason"`
+}
+
+func (e *PacketParseError) Error() string {
+	return e.Reason
+}
+
+// PacketParser parses telemetry lines, translating vendor specific state
+// names onto RocketState.
+type PacketParser struct {
+	states       map[string]RocketState
+	unknownState RocketState
+	unknownMode  string // "", models.UnknownStateLast or models.UnknownStateError
+	last         atomic.Int32
+	delimiter    string
+	maxGForce    float64
+	timeUnit     string
+}
+
+// NewPacketParser builds a parser from the configured state aliases.
+func NewPacketParser(cfg models.ParserConfig) (*PacketParser, error) {
+	p := &PacketParser{
+		states:    make(map[string]RocketState, len(stateNames)+len(cfg.StateAliases)),
+		delimiter: cfg.RecordDelimiter,
+		maxGForce: cfg.MaxGForce,
+		timeUnit:  cfg.TimeUnit,
+	}
+	switch cfg.TimeUnit {
+	case "", models.TimeUnitMilliseconds, models.TimeUnitSeconds, models.TimeUnitMicroseconds, models.TimeUnitAuto:
+	default:
+		return nil, fmt.Errorf("unknown time unit %q", cfg.TimeUnit)
+	}
+	for name, state := range stateNames {
+		p.states[name] = state
+	}
+	for alias, name := range cfg.StateAliases {
+		state, ok := stateNames[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("state alias %q maps to unknown state %q", alias, name)
+		}
+		p.states[strings.ToUpper(alias)] = state
+	}
+
+	switch mode := strings.ToLower(cfg.UnknownState); mode {
+	case models.UnknownStateLast, models.UnknownStateError:
+		p.unknownMode = mode
+		p.unknownState = UNKNOWN // until a state has been seen
+	default:
+		state, ok := stateNames[strings.ToUpper(cfg.UnknownState)]
+		if !ok {
+			return nil, fmt.Errorf("unknown state fallback %q is not a state", cfg.UnknownState)
+		}
+		p.unknownState = state
+	}
+	p.last.Store(int32(p.unknownState))
+
+	return p, nil
+}
+
+func mustParser(cfg models.ParserConfig) *PacketParser {
+	p, err := NewPacketParser(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *PacketParser) parseState(s string) (RocketState, error) {
+	if state, ok := p.states[strings.ToUpper(s)]; ok {
+		p.last.Store(int32(state))
+		return state, nil
+	}
+
+	switch p.unknownMode {
+	case models.UnknownStateError:
+		return UNKNOWN, fmt.Errorf("unknown state %q", s)
+	case models.UnknownStateLast:
+		last := RocketState(p.last.Load())
+		log.DefaultLogger.Warn("Unknown rocket state, keeping the last state", "state", s, "last", last)
+		return last, nil
+	}
+	log.DefaultLogger.Warn("Unknown rocket state", "state", s, "fallback", p.unknownState)
+	return p.unknownState, nil
+}
+
+// rssiPattern matches the "Received - RSSI: -89, Message: 1234,..." format.
+var rssiPattern = regexp.MustCompile(`RSSI:\s*(-?\d+),\s*Message:\s*(.+)`)
+
+// splitRSSI returns the message of a radio line and the RSSI it was received
+// with, -50 when the line does not report one.
+func splitRSSI(line string) (message string, rssi int) {
+	message, rssi = line, -50
+	if matches := rssiPattern.FindStringSubmatch(line); len(matches) == 3 {
+		if val, err := strconv.Atoi(matches[1]); err == nil {
+			rssi = val
+		}
+		message = strings.TrimSpace(matches[2])
+	}
+	return message, rssi
+}
+
+// Parse parses a radio packet line.
+func (p *PacketParser) Parse(packetString string) (*TelemetryPacket, error) {
+	message, rssi := splitRSSI(packetString)
+	return p.parseRecord(packetString, message, rssi)
+}
+
+// parseRecord parses a single radio record received in line.
+func (p *PacketParser) parseRecord(line, message string, rssi int) (*TelemetryPacket, error) {
+	parts := strings.Split(message, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	// Radio packet format: timestamp,pitch,roll,yaw,gforce,altitude,lat,lon,state,loops
+	// and, from avionics that report it, the vertical velocity in m/s.
+	if len(parts) != 10 && len(parts) != 11 {
+		return nil, &PacketParseError{Line: line, Reason: fmt.Sprintf("invalid packet length: expected 10 or 11 parts, got %d", len(parts))}
+	}
+
+	// Helper to parse float
+	parseFloat := func(s string) float64 {
+		val, _ := strconv.ParseFloat(s, 64)
+		return val
+	}
+
+	timestamp := parseFloat(parts[0])
+	pitch := parseFloat(parts[1])
+	roll := parseFloat(parts[2])
+	yaw := parseFloat(parts[3])
+	gforce := parseFloat(parts[4])
+	altitude := parseFloat(parts[5])
+	lat := parseFloat(parts[6])
+	lon := parseFloat(parts[7])
+
+	state, err := p.parseState(parts[8])
+	if err != nil {
+		return nil, &PacketParseError{Line: line, Reason: err.Error()}
+	}
+
+	loops := parseFloat(parts[9])
+
+	var velocity *float64
+	if len(parts) == 11 && parts[10] != "" {
+		v, err := strconv.ParseFloat(parts[10], 64)
+		if err != nil {
+			return nil, &PacketParseError{Line: line, Reason: fmt.Sprintf("invalid velocity %q", parts[10])}
+		}
+		velocity = &v
+	}
+
+	return p.clampGForce(&TelemetryPacket{
+		Signal:    rssi,
+		Timestamp: p.milliseconds(timestamp),
+		Pitch:     pitch,
+		Roll:      roll,
+		Yaw:       yaw,
+		GForce:    gforce,
+		Altitude:  altitude,
+		GPS: GPS{
+			Latitude:  lat,
+			Longitude: lon,
+		},
+		State:          state,
+		LoopsPerSecond: loops,
+		Velocity:       velocity,
+	}), nil
+}
+
+// milliseconds converts a parsed timestamp to the milliseconds packets carry.
+func (p *PacketParser) milliseconds(ts float64) float64 {
+	unit := p.timeUnit
+	if unit == models.TimeUnitAuto {
+		unit = guessTimeUnit(ts)
+	}
+	switch unit {
+	case models.TimeUnitSeconds:
+		return ts * 1000
+	case models.TimeUnitMicroseconds:
+		return ts / 1000
+	}
+	return ts
+}
+
+// inTimeUnit converts a packet's timestamp in milliseconds to unit, the
+// inverse of milliseconds. Auto guesses epoch milliseconds back as such.
+func inTimeUnit(ms float64, unit string) float64 {
+	switch unit {
+	case models.TimeUnitSeconds:
+		return ms / 1000
+	case models.TimeUnitMicroseconds:
+		return ms * 1000
+	}
+	return ms
+}
+
+// guessTimeUnit tells epoch seconds, milliseconds and microseconds apart by
+// magnitude: in seconds, this century is around 1e9 to 4e9.
+func guessTimeUnit(ts float64) string {
+	switch ts = math.Abs(ts); {
+	case ts >= 1e14:
+		return models.TimeUnitMicroseconds
+	case ts >= 1e11:
+		return models.TimeUnitMilliseconds
+	case ts >= 1e8:
+		return models.TimeUnitSeconds
+	}
+	return models.TimeUnitMilliseconds
+}
+
+// clampGForce limits the g-force of a parsed packet to the configured
+// maximum, flagging the packet when it had to.
+func (p *PacketParser) clampGForce(packet *TelemetryPacket) *TelemetryPacket {
+	if p.maxGForce <= 0 || math.Abs(packet.GForce) <= p.maxGForce {
+		return packet
+	}
+	log.DefaultLogger.Warn("Clamping implausible g-force", "gforce", packet.GForce, "max", p.maxGForce)
+	packet.GForce = math.Copysign(p.maxGForce, packet.GForce)
+	packet.GForceClamped = true
+	return packet
+}
+
+// ParseAny parses a line in any of the supported telemetry formats: a JSON
+// encoded TelemetryPacket or the radio packet format understood by Parse.
+func (p *PacketParser) ParseAny(line string) (*TelemetryPacket, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var packet TelemetryPacket
+		if err := json.Unmarshal([]byte(line), &packet); err != nil {
+			return nil, &PacketParseError{Line: line, Reason: fmt.Sprintf("invalid json packet: %v", err)}
+		}
+		packet.Timestamp = p.milliseconds(packet.Timestamp)
+		return p.clampGForce(&packet), nil
+	}
+	return p.Parse(line)
+}
+
+// ParsePackets parses a line that may carry several radio records joined by
+// the configured record delimiter, as sent by radios in burst mode. Each
+// record is parsed on its own and takes the RSSI of the line; the packets
+// that parse are returned along with an error for each record that does not.
+// JSON lines hold a single packet.
+func (p *PacketParser) ParsePackets(line string) ([]TelemetryPacket, []error) {
+	line = strings.TrimSpace(line)
+	if p.delimiter == "" || strings.HasPrefix(line, "{") {
+		packet, err := p.ParseAny(line)
+		if err != nil {
+			return nil, []error{err}
+		}
+		return []TelemetryPacket{*packet}, nil
+	}
+
+	message, rssi := splitRSSI(line)
+	var packets []TelemetryPacket
+	var errs []error
+	for _, record := range strings.Split(message, p.delimiter) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		packet, err := p.parseRecord(line, record, rssi)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		packets = append(packets, *packet)
+	}
+	return packets, errs
+}
@@ -0,0 +1,172 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestParserStateAliases(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Parser
+	cfg.StateAliases["SAFE"] = "calibration"
+	cfg.UnknownState = "APEX"
+
+	parser, err := NewPacketParser(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]RocketState{
+		"BOOST":     LAUNCHING,
+		"coast":     LAUNCHING,
+		"DROGUE":    DESCENDING,
+		"MAIN":      DESCENDING,
+		"LAND":      LANDED,
+		"LAUNCHING": LAUNCHING,
+		"SAFE":      CALIBRATION,
+		"WAT":       APEX,
+	}
+	for name, want := range tests {
+		packet, err := parser.Parse("1000,90,0,0,1.0,10,37.7,-122.4," + name + ",10")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet.State != want {
+			t.Errorf("state %q parsed as %d, want %d", name, packet.State, want)
+		}
+	}
+}
+
+func TestParserRejectsBadAlias(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Parser
+	cfg.StateAliases["BOOST"] = "WARP"
+	if _, err := NewPacketParser(cfg); err == nil {
+		t.Fatal("expected error for alias to unknown state")
+	}
+
+	cfg = models.DefaultPluginSettings().Parser
+	cfg.UnknownState = "WARP"
+	if _, err := NewPacketParser(cfg); err == nil {
+		t.Fatal("expected error for unknown fallback state")
+	}
+}
+
+func TestParserUnknownState(t *testing.T) {
+	line := func(state string) string { return "1000,90,0,0,1.0,10,37.7,-122.4," + state + ",10" }
+
+	for _, tc := range []struct {
+		mode      string
+		want      RocketState
+		wantError bool
+	}{
+		{mode: "", want: UNKNOWN}, // the default
+		{mode: "LANDED", want: LANDED},
+		{mode: models.UnknownStateLast, want: DESCENDING},
+		{mode: models.UnknownStateError, wantError: true},
+	} {
+		cfg := models.DefaultPluginSettings().Parser
+		if tc.mode != "" {
+			cfg.UnknownState = tc.mode
+		}
+		parser, err := NewPacketParser(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := parser.Parse(line("DESCENDING")); err != nil {
+			t.Fatal(err)
+		}
+		packet, err := parser.Parse(line("D#SC3ND"))
+		if tc.wantError {
+			if err == nil {
+				t.Errorf("mode %q: expected a parse error", tc.mode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("mode %q: %v", tc.mode, err)
+		}
+		if packet.State != tc.want {
+			t.Errorf("mode %q: garbled state parsed as %d, want %d", tc.mode, packet.State, tc.want)
+		}
+	}
+}
+
+func TestParsePacketsBurst(t *testing.T) {
+	line := "Received - RSSI: -72, Message: 1000,90,0,0,1,10,37.7,-122.4,LAUNCHING,10|" +
+		"1100,90,0,0,1,12,37.7,-122.4,LAUNCHING,10|garbage|" +
+		"1200,90,0,0,1,14,37.7,-122.4,LAUNCHING,10|"
+
+	packets, errs := ParsePackets(line)
+	if len(packets) != 3 || len(errs) != 1 {
+		t.Fatalf("got %d packets and %v, want 3 packets and one error", len(packets), errs)
+	}
+	for i, p := range packets {
+		if want := 10 + 2*float64(i); p.Altitude != want || p.Signal != -72 {
+			t.Errorf("packet %d: altitude %v signal %d, want %v at -72", i, p.Altitude, p.Signal, want)
+		}
+	}
+
+	cfg := models.DefaultPluginSettings().Parser
+	cfg.RecordDelimiter = ""
+	single, err := NewPacketParser(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packets, errs := single.ParsePackets(line); len(packets) != 0 || len(errs) != 1 {
+		t.Errorf("without a delimiter got %d packets and %v, want the line rejected", len(packets), errs)
+	}
+}
+
+func TestParserClampsGForce(t *testing.T) {
+	for line, want := range map[string]float64{
+		"1000,90,0,0,3000,10,37.7,-122.4,LAUNCHING,10": 100,
+		"1000,90,0,0,-250,10,37.7,-122.4,LAUNCHING,10": -100,
+		"1000,90,0,0,12.5,10,37.7,-122.4,LAUNCHING,10": 12.5,
+		`{"gforce": 5000, "state": 1}`:                 100,
+	} {
+		packet, err := ParseAny(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet.GForce != want || packet.GForceClamped != (want != 12.5) {
+			t.Errorf("%s: gforce %v clamped %v, want %v", line, packet.GForce, packet.GForceClamped, want)
+		}
+	}
+}
+
+func TestParserTimeUnits(t *testing.T) {
+	const epochMs = 1_700_000_000_500.0
+	for _, tc := range []struct {
+		unit, timestamp string
+		want            float64
+	}{
+		{unit: models.TimeUnitMilliseconds, timestamp: "1700000000500", want: epochMs},
+		{unit: models.TimeUnitSeconds, timestamp: "1700000000.5", want: epochMs},
+		{unit: models.TimeUnitMicroseconds, timestamp: "1700000000500000", want: epochMs},
+		{unit: models.TimeUnitAuto, timestamp: "1700000000.5", want: epochMs},
+		{unit: models.TimeUnitAuto, timestamp: "1700000000500", want: epochMs},
+		{unit: models.TimeUnitAuto, timestamp: "1700000000500000", want: epochMs},
+		{unit: models.TimeUnitAuto, timestamp: "1500", want: 1500}, // since boot
+	} {
+		cfg := models.DefaultPluginSettings().Parser
+		cfg.TimeUnit = tc.unit
+		parser, err := NewPacketParser(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packet, err := parser.Parse(tc.timestamp + ",90,0,0,1.0,10,37.7,-122.4,LAUNCHING,10")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet.Timestamp != tc.want {
+			t.Errorf("%s %s: got %v ms, want %v", tc.unit, tc.timestamp, packet.Timestamp, tc.want)
+		}
+	}
+
+	cfg := models.DefaultPluginSettings().Parser
+	cfg.TimeUnit = "fortnights"
+	if _, err := NewPacketParser(cfg); err == nil {
+		t.Error("unknown time unit accepted")
+	}
+}
@@ -0,0 +1,67 @@
+package plugin
+
+// PhaseTimes is how many seconds a flight spent in each phase: boost until
+// burnout, coast from burnout to the start of the descent, and descent until
+// touchdown.
+type PhaseTimes struct {
+	Boost   float64 `json:"timeInBoost"`
+	Coast   float64 `json:"timeInCoast"`
+	Descent float64 `json:"timeInDescent"`
+}
+
+// phaseTimer accumulates PhaseTimes from the phase entry timestamps of the
+// current flight, starting over at each launch. Like SummarizeFlight it takes
+// burnout where the climb rate stops rising.
+type phaseTimer struct {
+	boostAt, coastAt, descentAt, landedAt *float64 // s
+	peak                                  float64  // climb rate in boost, m/s
+	now                                   float64
+}
+
+// Update advances the timer to the packet and returns the times so far.
+func (p *phaseTimer) Update(packet TelemetryPacket, verticalSpeed float64) PhaseTimes {
+	t := packet.Timestamp / 1000
+	p.now = t
+
+	if packet.State == LAUNCHING && (p.boostAt == nil || p.landedAt != nil) {
+		*p = phaseTimer{boostAt: &t, peak: verticalSpeed, now: t}
+	}
+	if p.boostAt != nil {
+		switch {
+		case p.coastAt == nil && packet.State == LAUNCHING:
+			if verticalSpeed < p.peak {
+				p.coastAt = &t
+			}
+			p.peak = max(p.peak, verticalSpeed)
+		case p.coastAt == nil && (packet.State == APEX || packet.State == DESCENDING):
+			p.coastAt = &t
+		}
+		if p.descentAt == nil && packet.State == DESCENDING {
+			p.descentAt = &t
+		}
+		if p.descentAt != nil && p.landedAt == nil && packet.State == LANDED {
+			p.landedAt = &t
+		}
+	}
+	return p.Times()
+}
+
+// Times returns the seconds spent in each phase, counting the current one up
+// to the latest packet.
+func (p *phaseTimer) Times() PhaseTimes {
+	return PhaseTimes{
+		Boost:   p.span(p.boostAt, p.coastAt),
+		Coast:   p.span(p.coastAt, p.descentAt),
+		Descent: p.span(p.descentAt, p.landedAt),
+	}
+}
+
+func (p *phaseTimer) span(start, end *float64) float64 {
+	if start == nil {
+		return 0
+	}
+	if end == nil {
+		return p.now - *start
+	}
+	return *end - *start
+}
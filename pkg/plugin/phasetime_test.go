@@ -0,0 +1,34 @@
+package plugin
+
+import "testing"
+
+func TestPhaseTimer(t *testing.T) {
+	var p phaseTimer
+	for i, tc := range []struct {
+		timestamp float64
+		state     RocketState
+		vspeed    float64
+		want      PhaseTimes
+	}{
+		{0, LANDED, 0, PhaseTimes{}},
+		{1000, LAUNCHING, 50, PhaseTimes{}},
+		{2000, LAUNCHING, 120, PhaseTimes{Boost: 1}},
+		{3000, LAUNCHING, 150, PhaseTimes{Boost: 2}},
+		// Burnout: the climb rate drops.
+		{4000, LAUNCHING, 140, PhaseTimes{Boost: 3}},
+		{9000, APEX, 0, PhaseTimes{Boost: 3, Coast: 5}},
+		{10000, DESCENDING, -10, PhaseTimes{Boost: 3, Coast: 6}},
+		{40000, DESCENDING, -10, PhaseTimes{Boost: 3, Coast: 6, Descent: 30}},
+		{50000, LANDED, 0, PhaseTimes{Boost: 3, Coast: 6, Descent: 40}},
+		// Final until the next flight, which starts over.
+		{60000, LANDED, 0, PhaseTimes{Boost: 3, Coast: 6, Descent: 40}},
+		{70000, LAUNCHING, 80, PhaseTimes{}},
+		{71000, LAUNCHING, 60, PhaseTimes{Boost: 1}},
+		{72000, LAUNCHING, 40, PhaseTimes{Boost: 1, Coast: 1}},
+	} {
+		packet := TelemetryPacket{Timestamp: tc.timestamp, State: tc.state}
+		if got := p.Update(packet, tc.vspeed); got != tc.want {
+			t.Errorf("packet %d: got %+v, want %+v", i, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import "math"
+
+// PredictApogee estimates the apogee from the current altitude and climb rate
+// in m/s, assuming a drag-free coast. It is the altitude itself once the
+// rocket stops climbing.
+func PredictApogee(altitude, verticalSpeed float64) float64 {
+	if verticalSpeed <= 0 {
+		return altitude
+	}
+	return altitude + verticalSpeed*verticalSpeed/(2*standardGravity)
+}
+
+// FlightProgress places the rocket in [0, 1] through its flight: the ascent
+// covers the first half as a fraction of apogee, the descent the second.
+// A rocket still on the pad is at 0.
+func FlightProgress(state RocketState, altitude, apogee float64) float64 {
+	var progress float64
+	switch state {
+	case LAUNCHING:
+		if apogee > 0 {
+			progress = math.Min(altitude/apogee, 1) * 0.5
+		}
+	case APEX:
+		progress = 0.5
+	case DESCENDING:
+		progress = 0.5
+		if apogee > 0 {
+			progress += (1 - altitude/apogee) * 0.5
+		}
+	}
+	return math.Max(0, math.Min(1, progress))
+}
+
+// progressTracker follows flightProgress across a flight. The apogee is
+// predicted while climbing and the highest altitude reached afterwards, and
+// a rocket back on the ground after its descent stays at 1 until the next
+// launch.
+type progressTracker struct {
+	peak   float64
+	landed bool
+}
+
+func (p *progressTracker) Update(state RocketState, altitude, verticalSpeed float64) float64 {
+	switch state {
+	case IGNITION, LAUNCHING:
+		if p.landed {
+			p.peak, p.landed = 0, false
+		}
+	case DESCENDING:
+		p.landed = true
+	}
+	if state == LANDED || state == CALIBRATION || state == UNKNOWN {
+		if p.landed {
+			return 1
+		}
+		return 0
+	}
+	p.peak = math.Max(p.peak, altitude)
+	apogee := p.peak
+	if state == LAUNCHING {
+		apogee = math.Max(apogee, PredictApogee(altitude, verticalSpeed))
+	}
+	return FlightProgress(state, altitude, apogee)
+}
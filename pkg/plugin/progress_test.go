@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPredictApogee(t *testing.T) {
+	if got := PredictApogee(100, 0); got != 100 {
+		t.Errorf("stopped climbing: got %v, want 100", got)
+	}
+	if got := PredictApogee(100, -5); got != 100 {
+		t.Errorf("descending: got %v, want 100", got)
+	}
+	// 98.0665 m/s coasts for 10 s, 490.3 m higher.
+	if got := PredictApogee(100, 98.0665); math.Abs(got-590.3325) > 1e-9 {
+		t.Errorf("climbing: got %v, want 590.3325", got)
+	}
+}
+
+func TestFlightProgress(t *testing.T) {
+	tests := []struct {
+		state            RocketState
+		altitude, apogee float64
+		want             float64
+	}{
+		{LANDED, 0, 0, 0},
+		{IGNITION, 0, 0, 0},
+		{LAUNCHING, 0, 0, 0},
+		{LAUNCHING, 250, 1000, 0.125},
+		{LAUNCHING, 1000, 1000, 0.5},
+		{LAUNCHING, 1200, 1000, 0.5},
+		{APEX, 1000, 1000, 0.5},
+		{DESCENDING, 1000, 1000, 0.5},
+		{DESCENDING, 250, 1000, 0.875},
+		{DESCENDING, 0, 1000, 1},
+		{DESCENDING, -5, 1000, 1},
+	}
+	for _, tt := range tests {
+		if got := FlightProgress(tt.state, tt.altitude, tt.apogee); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("FlightProgress(%v, %v, %v) = %v, want %v", tt.state, tt.altitude, tt.apogee, got, tt.want)
+		}
+	}
+}
+
+func TestProgressTracker(t *testing.T) {
+	var p progressTracker
+	for i, tc := range []struct {
+		state            RocketState
+		altitude, vspeed float64
+		want             float64
+	}{
+		{LANDED, 0, 0, 0},
+		// Predicted apogee 100 + 49.03 m.
+		{LAUNCHING, 100, 31.0109, 0.3357},
+		{LAUNCHING, 140, 10, 0.4824},
+		{APEX, 150, 0, 0.5},
+		{DESCENDING, 75, -10, 0.75},
+		{DESCENDING, 0, -10, 1},
+		{LANDED, 0, 0, 1},
+		{LANDED, 0, 0, 1},
+		// The next flight starts over.
+		{IGNITION, 0, 0, 0},
+		{LAUNCHING, 10, 0, 0.5},
+	} {
+		if got := p.Update(tc.state, tc.altitude, tc.vspeed); math.Abs(got-tc.want) > 1e-3 {
+			t.Errorf("packet %d: progress = %.4f, want %v", i, got, tc.want)
+		}
+	}
+}
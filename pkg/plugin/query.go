@@ -1,5 +1,266 @@
 package plugin
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
 type Query struct {
+	// QueryType is "" for telemetry frames, or QueryTypeEvents for the flight
+	// event log instead.
+	QueryType string `json:"queryType"`
+	// Fields lists the fields to emit, see fieldRegistry; all of them when
+	// empty. "altitude" is the altitude as received (after ZeroAltitude and
+	// ClampAltitude), "rawAltitude" the same before clamping and
+	// "filteredAltitude" the altitude smoothed by Filter. Any combination can be
+	// requested to overlay them in one panel.
 	Fields []string `json:"fields"`
+	// MaxPoints caps the rows returned by a historical query, falling back to
+	// the panel's max data points when unset.
+	MaxPoints int `json:"maxPoints"`
+	// AttitudePrecision is the number of decimals in the attitude string.
+	AttitudePrecision int `json:"attitudePrecision"`
+	// Units picks the unit per field, e.g. {"altitude": "ft"}. Fields without
+	// an entry use their default unit from the field registry.
+	Units map[string]string `json:"units"`
+	// OmitUnchanged drops stream fields whose value has not changed since the
+	// previous frame, see frameDelta for how to reconstruct them.
+	OmitUnchanged bool `json:"omitUnchanged"`
+	// KeyframeInterval is how often, in frames, all fields are sent anyway.
+	KeyframeInterval int `json:"keyframeInterval"`
+	// Filter configures the filter behind filteredAltitude.
+	Filter FilterConfig `json:"filter"`
+	// Deadband suppresses changes smaller than a threshold per numeric field,
+	// e.g. {"pitch": 0.2}: the previously emitted value is repeated instead.
+	// Thresholds are in the field's default unit.
+	Deadband map[string]float64 `json:"deadband"`
+	// MinAltitude drops historical packets below it, in meters as received
+	// (before ZeroAltitude), to leave out the time on the pad.
+	MinAltitude *float64 `json:"minAltitude"`
+	// Summary adds a "summary" frame to historical queries with the apogee,
+	// peak climb rate and coast efficiency over the time range.
+	Summary bool `json:"summary"`
+	// SmoothState emits state through a stateSequencer, so it only advances
+	// through the flight sequence. A backward jump is only taken once it has
+	// been reported for StateResetAfter packets in a row.
+	SmoothState     bool `json:"smoothState"`
+	StateResetAfter int  `json:"stateResetAfter"`
+	// SignalPerReceiver adds a signal field labeled with the receiver for
+	// each configured source when failing over between several, next to the
+	// merged signal. A receiver's field is null while it is not delivering.
+	SignalPerReceiver bool `json:"signalPerReceiver"`
+	// LossWindow is how many received packets packetLossPercent is computed
+	// over.
+	LossWindow int `json:"lossWindow"`
+	// GForceWindow is how many received packets gforceAvg averages over.
+	GForceWindow int `json:"gforceWindow"`
+	// StabilityWindow is how many received packets stabilityIndex looks back
+	// over.
+	StabilityWindow int `json:"stabilityWindow"`
+	// ApogeeSmoothing is the weight, in (0, 1], apogeeEstimate gives each
+	// higher prediction while climbing. 1 follows the prediction upwards
+	// without smoothing.
+	ApogeeSmoothing float64 `json:"apogeeSmoothing"`
+	// PhaseBy decides which of altitudeAscent and altitudeDescent carries the
+	// altitude: PhaseByState (default) or PhaseByVelocity.
+	PhaseBy string `json:"phaseBy"`
+	// Seed overrides the datasource's simulation seed for this stream, so
+	// panels can pin the same or different noise. 0 picks a random seed.
+	Seed *int64 `json:"seed"`
+	// LaunchAzimuth is the direction of the launch in degrees clockwise from
+	// north, along which downrange is measured. Unset, it is the initial
+	// direction of travel of each flight.
+	LaunchAzimuth *float64 `json:"launchAzimuth"`
+	// Rocket picks which rocket of the simulated formation the stream
+	// follows, counting from 0.
+	Rocket int `json:"rocket"`
+	// WarmupDiscard trims the start of a historical query's output.
+	WarmupDiscard WarmupDiscard `json:"warmupDiscard"`
+}
+
+// WarmupDiscard drops the packets of the first Seconds, and at least the
+// first Samples packets, of a historical query from its rows and summary,
+// before decimation. The dropped packets still run through the frame
+// builder, so filters and derived fields have converged by the first row
+// returned.
+type WarmupDiscard struct {
+	Seconds float64 `json:"seconds"`
+	Samples int     `json:"samples"`
+}
+
+// Count is the number of packets at the start of packets in the warmup.
+func (w WarmupDiscard) Count(packets []TelemetryPacket) int {
+	n := 0
+	for n < len(packets) && (n < w.Samples || (packets[n].Timestamp-packets[0].Timestamp)/1000 < w.Seconds) {
+		n++
+	}
+	return n
+}
+
+// QueryTypeEvents queries the event log, see eventsFrame.
+const QueryTypeEvents = "events"
+
+const (
+	PhaseByState    = "state"
+	PhaseByVelocity = "velocity"
+)
+
+// defaultQuery returns the options applied to a query before its JSON is
+// decoded on top.
+func defaultQuery() Query {
+	return Query{
+		AttitudePrecision: 1,
+		KeyframeInterval:  20,
+		StateResetAfter:   5,
+		LossWindow:        20,
+		GForceWindow:      10,
+		StabilityWindow:   20,
+		ApogeeSmoothing:   0.2,
+		PhaseBy:           PhaseByState,
+		Filter: FilterConfig{
+			Type:             FilterEMA,
+			Alpha:            0.3,
+			ProcessNoise:     2,
+			MeasurementNoise: 3,
+		},
+	}
+}
+
+const maxAttitudePrecision = 6
+
+// parseQuery decodes a query's JSON on top of the defaults and normalizes it.
+// An empty query runs with the defaults.
+func parseQuery(raw []byte) (Query, error) {
+	q := defaultQuery()
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &q); err != nil {
+			return q, fmt.Errorf("json unmarshal: %w", err)
+		}
+	}
+	return q.Normalize()
+}
+
+// Normalize returns a copy of the query ready to execute: fields are
+// deduplicated, intervals clamped to their valid range and the remaining
+// options validated. Both the stream and the historical path run every query
+// through it.
+func (q Query) Normalize() (Query, error) {
+	q.Fields = dedupeFields(q.Fields)
+	for _, f := range q.Fields {
+		if _, ok := lookupField(f); !ok && f != "time" {
+			return q, fmt.Errorf("unknown field %q", f)
+		}
+	}
+
+	if q.MaxPoints < 0 {
+		return q, fmt.Errorf("maxPoints must not be negative, got %d", q.MaxPoints)
+	}
+	q.AttitudePrecision = clampInt(q.AttitudePrecision, 0, maxAttitudePrecision)
+	q.KeyframeInterval = max(q.KeyframeInterval, 1)
+	q.StateResetAfter = max(q.StateResetAfter, 1)
+	q.LossWindow = max(q.LossWindow, 1)
+	q.GForceWindow = max(q.GForceWindow, 1)
+	q.StabilityWindow = max(q.StabilityWindow, 2)
+
+	for name, id := range q.Units {
+		info, ok := lookupField(name)
+		if !ok || len(info.Units) == 0 {
+			return q, fmt.Errorf("field %q has no unit choices", name)
+		}
+		if id != "" && findUnit(info.Units, id).ID != id {
+			return q, fmt.Errorf("unknown unit %q for field %q", id, name)
+		}
+	}
+	for name, band := range q.Deadband {
+		if _, ok := lookupField(name); !ok {
+			return q, fmt.Errorf("deadband for unknown field %q", name)
+		}
+		if band < 0 {
+			return q, fmt.Errorf("deadband for %q must not be negative", name)
+		}
+	}
+
+	if q.ApogeeSmoothing <= 0 || q.ApogeeSmoothing > 1 {
+		return q, fmt.Errorf("apogeeSmoothing must be in (0, 1], got %v", q.ApogeeSmoothing)
+	}
+
+	if q.WarmupDiscard.Seconds < 0 || q.WarmupDiscard.Samples < 0 {
+		return q, fmt.Errorf("warmupDiscard must not be negative")
+	}
+
+	switch q.QueryType {
+	case "", QueryTypeEvents:
+	default:
+		return q, fmt.Errorf("unknown queryType %q", q.QueryType)
+	}
+
+	if q.Rocket < 0 {
+		return q, fmt.Errorf("rocket must not be negative, got %d", q.Rocket)
+	}
+
+	switch q.PhaseBy {
+	case "", PhaseByState, PhaseByVelocity:
+	default:
+		return q, fmt.Errorf("unknown phaseBy %q", q.PhaseBy)
+	}
+
+	switch q.Filter.Type {
+	case "", FilterEMA:
+		if q.Filter.Alpha <= 0 || q.Filter.Alpha > 1 {
+			return q, fmt.Errorf("filter alpha must be in (0, 1], got %v", q.Filter.Alpha)
+		}
+	case FilterKalman:
+		if q.Filter.ProcessNoise <= 0 || q.Filter.MeasurementNoise <= 0 {
+			return q, fmt.Errorf("kalman filter noise must be positive")
+		}
+	default:
+		return q, fmt.Errorf("unknown filter %q", q.Filter.Type)
+	}
+
+	return q, nil
+}
+
+func clampInt(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
+
+// dedupeFields drops repeated field names, keeping the first occurrence of
+// each in order.
+func dedupeFields(fields []string) []string {
+	seen := make(map[string]bool, len(fields))
+	unique := fields[:0:0]
+	for _, f := range fields {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		unique = append(unique, f)
+	}
+	if len(unique) < len(fields) {
+		log.DefaultLogger.Debug("Dropped duplicate query fields", "fields", fields, "kept", unique)
+	}
+	return unique
+}
+
+// shouldInclude reports whether a field was requested by the query.
+func (q Query) shouldInclude(field string) bool {
+	if len(q.Fields) == 0 {
+		return true // Default to all if none specified
+	}
+	for _, f := range q.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// keepHistory reports whether a historical packet passes the query's filters.
+func (q Query) keepHistory(p TelemetryPacket) bool {
+	if q.MinAltitude != nil && p.Altitude < *q.MinAltitude {
+		return false
+	}
+	return true
 }
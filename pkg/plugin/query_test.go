@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestParseQueryDedupesFields(t *testing.T) {
+	q, err := parseQuery([]byte(`{"fields":["altitude","pitch","altitude","roll","pitch"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"altitude", "pitch", "roll"}; !slices.Equal(q.Fields, want) {
+		t.Errorf("fields = %v, want %v", q.Fields, want)
+	}
+
+	frame := newFrameBuilder(q, models.DefaultPluginSettings()).Build(TelemetryPacket{})
+	if len(frame.Fields) != 4 {
+		t.Errorf("got %d fields, want time plus 3", len(frame.Fields))
+	}
+}
+
+func TestNormalizeClampsAndDefaults(t *testing.T) {
+	q, err := parseQuery(nil)
+	if err != nil {
+		t.Fatalf("empty query: %v", err)
+	}
+	if q.AttitudePrecision != 1 || q.KeyframeInterval != 20 {
+		t.Errorf("empty query did not get the defaults: %+v", q)
+	}
+
+	q, err = parseQuery([]byte(`{"attitudePrecision":12,"keyframeInterval":-3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.AttitudePrecision != maxAttitudePrecision || q.KeyframeInterval != 1 {
+		t.Errorf("got precision %d and keyframe interval %d, want them clamped", q.AttitudePrecision, q.KeyframeInterval)
+	}
+}
+
+func TestNormalizeRejectsBadOptions(t *testing.T) {
+	for _, raw := range []string{
+		`{"fields":["altitude","warp"]}`,
+		`{"maxPoints":-1}`,
+		`{"units":{"altitude":"furlong"}}`,
+		`{"units":{"gpsFix":"m"}}`,
+		`{"deadband":{"pitch":-1}}`,
+		`{"filter":{"type":"median"}}`,
+		`{"filter":{"alpha":2}}`,
+		`{"filter":{"type":"kalman","processNoise":0}}`,
+		`{"apogeeSmoothing":0}`,
+	} {
+		if _, err := parseQuery([]byte(raw)); err == nil {
+			t.Errorf("%s: expected an error", raw)
+		}
+	}
+}
+
+func TestNormalizeReturnsCopy(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"pitch", "pitch"}
+	n, err := q.Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Fields) != 2 || len(n.Fields) != 1 {
+		t.Errorf("original fields %v, normalized %v", q.Fields, n.Fields)
+	}
+}
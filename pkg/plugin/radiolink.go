@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"math"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+const radioReferenceDistance = 100.0 // m
+
+// radioLink decides which simulated packets reach the ground station and
+// with what RSSI, based on the rocket's range from it.
+type radioLink struct {
+	cfg     models.RadioConfig
+	station *GPS
+	last    GPS // last fix, for packets without one
+}
+
+func newRadioLink(cfg models.RadioConfig) *radioLink {
+	l := &radioLink{cfg: cfg}
+	if cfg.Latitude != 0 || cfg.Longitude != 0 {
+		l.station = &GPS{Latitude: cfg.Latitude, Longitude: cfg.Longitude}
+	}
+	return l
+}
+
+// Receive sets the RSSI of a packet and reports whether it was received.
+// Every packet is received, unchanged, while the model is off.
+func (l *radioLink) Receive(p *TelemetryPacket) bool {
+	if l.cfg.MaxRange <= 0 {
+		return true
+	}
+	if !p.GPSNoFix {
+		l.last = p.GPS
+		if l.station == nil {
+			station := p.GPS // the first fix is the launch site
+			l.station = &station
+		}
+	}
+
+	var ground float64
+	if l.station != nil {
+		ground = Haversine(l.station.Latitude, l.station.Longitude, l.last.Latitude, l.last.Longitude)
+	}
+	distance := math.Hypot(ground, p.Altitude)
+	rssi := RadioRSSI(distance, l.cfg)
+	p.Signal = int(math.Round(rssi))
+	return distance <= l.cfg.MaxRange && rssi >= l.cfg.Sensitivity
+}
+
+// RadioRSSI is the log-distance path loss model of cfg in dBm at a slant
+// range in meters. Ranges within the reference distance get the reference
+// signal.
+func RadioRSSI(distance float64, cfg models.RadioConfig) float64 {
+	distance = max(distance, radioReferenceDistance)
+	return cfg.ReferenceSignal - 10*cfg.PathLossExponent*math.Log10(distance/radioReferenceDistance)
+}
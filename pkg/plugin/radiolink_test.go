@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestRadioRSSI(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation.Radio
+	for distance, want := range map[float64]float64{
+		10:     -50,
+		100:    -50,
+		1000:   -70,
+		10000:  -90,
+		100000: -110,
+	} {
+		if got := RadioRSSI(distance, cfg); math.Abs(got-want) > 1e-9 {
+			t.Errorf("RSSI at %vm = %v, want %v", distance, got, want)
+		}
+	}
+}
+
+func TestRadioLinkDropsOutOfRange(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation.Radio
+	cfg.MaxRange = 5000
+	link := newRadioLink(cfg)
+
+	pad := GPS{Latitude: 37.7749, Longitude: -122.4194}
+	for _, tc := range []struct {
+		altitude float64
+		fix      bool
+		want     bool
+	}{
+		{altitude: 0, fix: true, want: true}, // sets the station
+		{altitude: 4000, fix: true, want: true},
+		{altitude: 6000, fix: true, want: false},
+		{altitude: 6000, fix: false, want: false},
+		{altitude: 3000, fix: true, want: true}, // recovers on the way down
+	} {
+		p := TelemetryPacket{Altitude: tc.altitude, GPS: pad, GPSNoFix: !tc.fix}
+		if !tc.fix {
+			p.GPS = GPS{}
+		}
+		if got := link.Receive(&p); got != tc.want {
+			t.Errorf("at %vm: received %v, want %v", tc.altitude, got, tc.want)
+		}
+		if want := int(math.Round(RadioRSSI(tc.altitude, cfg))); p.Signal != want {
+			t.Errorf("at %vm: signal %d, want %d", tc.altitude, p.Signal, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package plugin
+
+// railExit catches the moment the rocket clears the launch rail: the first
+// packet of each flight at least length m above the pad. The pad is wherever
+// the rocket last sat before launching, so altitudes need not be zeroed.
+type railExit struct {
+	length  float64
+	pad     float64
+	cleared bool
+}
+
+// Update reports the climb rate in m/s on the packet that clears the rail,
+// the reported velocity if there is one, or else verticalSpeed.
+func (r *railExit) Update(packet TelemetryPacket, verticalSpeed float64) (float64, bool) {
+	switch packet.State {
+	case LANDED, CALIBRATION, IGNITION:
+		r.pad, r.cleared = packet.Altitude, false
+		return 0, false
+	case LAUNCHING:
+		if r.length <= 0 || r.cleared || packet.Altitude-r.pad < r.length {
+			return 0, false
+		}
+		r.cleared = true
+		if packet.Velocity != nil {
+			return *packet.Velocity, true
+		}
+		return verticalSpeed, true
+	}
+	return 0, false
+}
@@ -0,0 +1,46 @@
+package plugin
+
+import "testing"
+
+func TestRailExit(t *testing.T) {
+	velocity := func(v float64) *float64 { return &v }
+	r := railExit{length: 2}
+	for i, tc := range []struct {
+		packet TelemetryPacket
+		vspeed float64
+		want   float64
+		ok     bool
+	}{
+		// Altitudes above sea level, the pad at 100 m.
+		{packet: TelemetryPacket{Altitude: 100, State: LANDED}},
+		{packet: TelemetryPacket{Altitude: 101, State: LAUNCHING}, vspeed: 10},
+		{packet: TelemetryPacket{Altitude: 103, State: LAUNCHING}, vspeed: 20, want: 20, ok: true},
+		{packet: TelemetryPacket{Altitude: 110, State: LAUNCHING}, vspeed: 35},
+		{packet: TelemetryPacket{Altitude: 100, State: LANDED}},
+		// The next flight prefers the reported velocity.
+		{packet: TelemetryPacket{Altitude: 105, State: LAUNCHING, Velocity: velocity(25)}, vspeed: 50, want: 25, ok: true},
+	} {
+		got, ok := r.Update(tc.packet, tc.vspeed)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("packet %d: got (%v, %v), want (%v, %v)", i, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	disabled := railExit{}
+	disabled.Update(TelemetryPacket{State: LANDED}, 0)
+	if _, ok := disabled.Update(TelemetryPacket{Altitude: 50, State: LAUNCHING}, 30); ok {
+		t.Error("rail exit reported without a rail length")
+	}
+}
+
+func TestSummaryRailExitVelocity(t *testing.T) {
+	f := Flight{Packets: []TelemetryPacket{
+		{Timestamp: 0, Altitude: 0, State: LANDED},
+		{Timestamp: 100, Altitude: 1, State: LAUNCHING},
+		{Timestamp: 200, Altitude: 4, State: LAUNCHING},
+		{Timestamp: 300, Altitude: 9, State: LAUNCHING},
+	}}
+	if s := SummarizeFlight(f, 2); s.RailExitVelocity != 30 {
+		t.Errorf("rail exit velocity = %v, want 30", s.RailExitVelocity)
+	}
+}
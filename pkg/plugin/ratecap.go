@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"context"
+	"time"
+)
+
+// cappedSource limits a source to one packet per interval. Packets arriving
+// faster are coalesced: only the most recent one is kept and forwarded once
+// the interval has passed, so a runaway source cannot flood the stream but
+// the output never falls behind.
+type cappedSource struct {
+	source   Source
+	interval time.Duration
+	now      func() time.Time
+}
+
+func (c *cappedSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan TelemetryPacket)
+	done := make(chan error, 1)
+	go func() { done <- c.source.Run(ctx, in) }()
+
+	var pending *TelemetryPacket
+	var next time.Time
+	for {
+		// Only offer the pending packet once the interval has passed; until
+		// then wake up when it does.
+		var send chan<- TelemetryPacket
+		var wait <-chan time.Time
+		var packet TelemetryPacket
+		if pending != nil {
+			if d := next.Sub(c.now()); d > 0 {
+				wait = time.After(d)
+			} else {
+				send, packet = out, *pending
+			}
+		}
+
+		select {
+		case p := <-in:
+			if pending != nil {
+				packetsCoalesced.Inc()
+			}
+			pending = &p
+		case send <- packet:
+			pending = nil
+			next = c.now().Add(c.interval)
+		case <-wait:
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
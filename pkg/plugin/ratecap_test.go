@@ -0,0 +1,43 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCappedSourceCoalescesBurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	burst := make(chanSource)
+	c := &cappedSource{source: burst, interval: 50 * time.Millisecond, now: time.Now}
+	out := make(chan TelemetryPacket)
+	go c.Run(ctx, out)
+
+	coalesced := testutil.ToFloat64(packetsCoalesced)
+	start := time.Now()
+	go func() {
+		for i := 1; i <= 500; i++ {
+			burst <- TelemetryPacket{Timestamp: float64(i)}
+		}
+	}()
+
+	var got []TelemetryPacket
+	for len(got) == 0 || got[len(got)-1].Timestamp != 500 {
+		got = append(got, receive(t, out))
+	}
+	elapsed := time.Since(start)
+
+	if limit := int(elapsed/c.interval) + 1; len(got) > limit {
+		t.Errorf("forwarded %d packets in %v, cap allows %d", len(got), elapsed, limit)
+	}
+	if len(got) == 500 {
+		t.Error("burst was not coalesced")
+	}
+	if n := testutil.ToFloat64(packetsCoalesced) - coalesced; int(n) != 500-len(got) {
+		t.Errorf("coalesced counter rose by %v, want %d", n, 500-len(got))
+	}
+}
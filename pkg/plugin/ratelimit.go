@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// tokenBucket allows bursts of up to burst requests, refilling at rate tokens
+// per second.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(limit models.RateLimit, now func() time.Time) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, last: now(), now: now}
+}
+
+// Allow takes a token if one is available.
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter keeps one token bucket per resource endpoint.
+type rateLimiter struct {
+	cfg models.RateLimitConfig
+	now func() time.Time
+}
+
+func newRateLimiter(cfg models.RateLimitConfig) *rateLimiter {
+	return &rateLimiter{cfg: cfg, now: time.Now}
+}
+
+// wrap limits requests to h using the limit configured for path, responding
+// with 429 Too Many Requests once the bucket is empty.
+func (l *rateLimiter) wrap(path string, h http.Handler) http.Handler {
+	limit, ok := l.cfg.Endpoints[path]
+	if !ok {
+		limit = l.cfg.Default
+	}
+	if limit.Rate <= 0 {
+		return h
+	}
+
+	bucket := newTokenBucket(limit, l.now)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bucket.Allow() {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
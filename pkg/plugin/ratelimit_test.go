@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.UnixMilli(0)
+	bucket := newTokenBucket(models.RateLimit{Rate: 2, Burst: 3}, func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		if !bucket.Allow() {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+	if bucket.Allow() {
+		t.Fatal("request beyond burst was allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if !bucket.Allow() {
+		t.Fatal("refilled token was not available")
+	}
+	if bucket.Allow() {
+		t.Fatal("only one token should have refilled")
+	}
+}
+
+func TestRateLimiterEndpointOverride(t *testing.T) {
+	limiter := newRateLimiter(models.RateLimitConfig{
+		Default:   models.RateLimit{Rate: 100, Burst: 100},
+		Endpoints: map[string]models.RateLimit{"/latest": {Rate: 1, Burst: 1}},
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+	h := limiter.wrap("/latest", ok)
+
+	codes := []int{}
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/latest", nil))
+		codes = append(codes, rec.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Fatalf("got status codes %v, want [200 429]", codes)
+	}
+}
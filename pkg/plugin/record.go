@@ -0,0 +1,236 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// recordBlockSize is how many packets a columnar recording buffers into each
+// block, a minute of the default stream rate. A crash loses at most the
+// packets of the unfinished block.
+const recordBlockSize = 120
+
+// recorder appends streamed packets to a capture file, in the radio CSV
+// format or columnar. CSV timestamps are written in the parser's time unit,
+// so history reads the recording back with the same settings. A nil recorder
+// records nothing.
+type recorder struct {
+	mu       sync.Mutex
+	path     string
+	format   string
+	timeUnit string
+	file     *os.File
+	pending  []TelemetryPacket
+	claimed  bool
+}
+
+func newRecorder(cfg models.RecordingConfig, timeUnit string) (*recorder, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	format := cfg.Format
+	switch format {
+	case "":
+		format = models.FormatCSV
+	case models.FormatCSV, models.FormatColumnar:
+	default:
+		return nil, fmt.Errorf("unknown recording format %q", cfg.Format)
+	}
+	if err := checkRecordingFormat(cfg.File, format); err != nil {
+		return nil, err
+	}
+	return &recorder{path: cfg.File, format: format, timeUnit: timeUnit}, nil
+}
+
+// checkRecordingFormat refuses to append to an existing capture in the other
+// format, which would leave a file neither format reads.
+func checkRecordingFormat(path, format string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	prefix, _ := bufio.NewReader(f).Peek(len(columnarMagic))
+	if len(prefix) == 0 {
+		return nil
+	}
+	if isColumnar(prefix) != (format == models.FormatColumnar) {
+		return fmt.Errorf("recording %s is not a %s capture", path, format)
+	}
+	return nil
+}
+
+// claim reserves the recording for one source. Every stream runs its own
+// source, so recording them all would repeat each packet once per panel, or
+// interleave separate simulated flights. The first stream to start records
+// until it stops.
+func (r *recorder) claim() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.claimed {
+		return false
+	}
+	r.claimed = true
+	return true
+}
+
+func (r *recorder) release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claimed = false
+}
+
+// Add records a packet. Write errors are logged rather than failing the
+// stream.
+func (r *recorder) Add(packet TelemetryPacket) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, packet)
+	if r.format == models.FormatColumnar && len(r.pending) < recordBlockSize {
+		return
+	}
+	if err := r.flush(); err != nil {
+		log.DefaultLogger.Error("Failed to record packets", "file", r.path, "error", err)
+	}
+}
+
+// Close writes out any buffered packets.
+func (r *recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.flush()
+	if r.file != nil {
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+		r.file = nil
+	}
+	return err
+}
+
+func (r *recorder) flush() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	if r.file == nil {
+		f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		r.file = f
+	}
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if r.format == models.FormatColumnar {
+		if info.Size() == 0 {
+			b.WriteString(columnarMagic)
+			b.WriteByte(columnarVersion)
+		}
+		writeColumnarBlock(&b, r.pending)
+	} else {
+		b.Write(marshalCapture(r.pending, r.timeUnit))
+	}
+	r.pending = r.pending[:0]
+	if _, err := r.file.Write(b.Bytes()); err != nil {
+		// Drop a partial write so the next one starts on a record boundary.
+		if terr := r.file.Truncate(info.Size()); terr != nil {
+			log.DefaultLogger.Error("Failed to trim partial recording", "file", r.path, "error", terr)
+		}
+		return err
+	}
+	return nil
+}
+
+// marshalCapture writes packets as radio lines with timestamps in unit.
+func marshalCapture(packets []TelemetryPacket, unit string) []byte {
+	if unit == "" || unit == models.TimeUnitMilliseconds || unit == models.TimeUnitAuto {
+		return MarshalRadioCSV(packets, false)
+	}
+	scaled := make([]TelemetryPacket, len(packets))
+	for i, p := range packets {
+		p.Timestamp = inTimeUnit(p.Timestamp, unit)
+		scaled[i] = p
+	}
+	return MarshalRadioCSV(scaled, false)
+}
+
+// recordingSource records the packets of a source while it holds the
+// datasource's recording.
+type recordingSource struct {
+	source Source
+	rec    *recorder
+}
+
+func (s *recordingSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	if !s.rec.claim() {
+		return s.source.Run(ctx, out)
+	}
+	defer s.rec.release()
+
+	packets := make(chan TelemetryPacket)
+	sourceErr := make(chan error, 1)
+	go func() {
+		sourceErr <- s.source.Run(ctx, packets)
+	}()
+	for {
+		select {
+		case err := <-sourceErr:
+			return err
+		case packet := <-packets:
+			s.rec.Add(packet)
+			select {
+			case out <- packet:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// ConvertCapture rewrites the capture at src, in either format, to dst in
+// format. A CSV capture is read, and written, with parserCfg, and lines that
+// do not parse are dropped.
+func ConvertCapture(src, dst, format string, parserCfg models.ParserConfig) error {
+	parser, err := NewPacketParser(parserCfg)
+	if err != nil {
+		return err
+	}
+	packets, err := readPackets(src, parser, nil)
+	if err != nil {
+		return err
+	}
+	var out []byte
+	switch format {
+	case models.FormatCSV:
+		out = marshalCapture(packets, parserCfg.TimeUnit)
+	case models.FormatColumnar:
+		out = MarshalColumnar(packets)
+	default:
+		return fmt.Errorf("unknown capture format %q", format)
+	}
+	return os.WriteFile(dst, out, 0o644)
+}
@@ -0,0 +1,187 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestRecorder(t *testing.T) {
+	for _, unit := range []string{models.TimeUnitMilliseconds, models.TimeUnitSeconds} {
+		parserCfg := models.DefaultPluginSettings().Parser
+		parserCfg.TimeUnit = unit
+		parser, err := NewPacketParser(parserCfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, format := range []string{models.FormatCSV, models.FormatColumnar} {
+			path := filepath.Join(t.TempDir(), "flight.log")
+			// Two recorders on the same file, as across plugin restarts.
+			for run := 0; run < 2; run++ {
+				r, err := newRecorder(models.RecordingConfig{File: path, Format: format}, unit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 0; i < recordBlockSize+5; i++ {
+					r.Add(TelemetryPacket{Timestamp: float64(run*1000000 + i*500), Altitude: 10, State: LAUNCHING, LoopsPerSecond: 10})
+				}
+				if err := r.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := readPackets(path, parser, nil)
+			if err != nil {
+				t.Fatalf("%s %s: %v", unit, format, err)
+			}
+			if want := 2 * (recordBlockSize + 5); len(got) != want {
+				t.Errorf("%s %s: read %d packets, want %d", unit, format, len(got), want)
+			} else if last := got[len(got)-1].Timestamp; last != 1000000+(recordBlockSize+4)*500 {
+				t.Errorf("%s %s: last packet at %v", unit, format, last)
+			}
+		}
+	}
+
+	if _, err := newRecorder(models.RecordingConfig{File: "x", Format: "parquet"}, ""); err == nil {
+		t.Error("want an error for an unknown format")
+	}
+	if r, _ := newRecorder(models.RecordingConfig{Format: models.FormatCSV}, ""); r != nil {
+		t.Error("want no recorder without a file")
+	}
+}
+
+func TestRecorderRejectsOtherFormat(t *testing.T) {
+	dir := t.TempDir()
+	csvPath, colPath := filepath.Join(dir, "flight.log"), filepath.Join(dir, "flight.col")
+	packets := []TelemetryPacket{{Timestamp: 1000, State: LANDED}}
+	if err := os.WriteFile(csvPath, MarshalRadioCSV(packets, false), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(colPath, MarshalColumnar(packets), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newRecorder(models.RecordingConfig{File: csvPath, Format: models.FormatColumnar}, ""); err == nil {
+		t.Error("columnar recording appended to a CSV capture")
+	}
+	if _, err := newRecorder(models.RecordingConfig{File: colPath, Format: models.FormatCSV}, ""); err == nil {
+		t.Error("CSV recording appended to a columnar capture")
+	}
+	if _, err := newRecorder(models.RecordingConfig{File: colPath, Format: models.FormatColumnar}, ""); err != nil {
+		t.Errorf("columnar recording refused to resume: %v", err)
+	}
+}
+
+func TestRecordingSourceRecordsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight.log")
+	rec, err := newRecorder(models.RecordingConfig{File: path, Format: models.FormatCSV}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	packets := []TelemetryPacket{{Timestamp: 1000, State: LANDED}, {Timestamp: 1500, State: LANDED}}
+
+	// The first stream holds the recording while the second runs alongside.
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(blockingSource)
+	first := &recordingSource{source: started, rec: rec}
+	running := make(chan error, 1)
+	go func() { running <- first.Run(ctx, make(chan TelemetryPacket)) }()
+	<-started
+	second := &recordingSource{source: sliceSource(packets), rec: rec}
+	drain(t, second)
+	cancel()
+	<-running
+
+	third := &recordingSource{source: sliceSource(packets), rec: rec}
+	drain(t, third)
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := MarshalRadioCSV(packets, false); string(got) != string(want) {
+		t.Errorf("recorded\n%s\nwant the third stream's packets once\n%s", got, want)
+	}
+}
+
+// blockingSource is closed once running, then delivers nothing until
+// cancelled.
+type blockingSource chan struct{}
+
+func (b blockingSource) Run(ctx context.Context, _ chan<- TelemetryPacket) error {
+	close(b)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// sliceSource delivers its packets and stops.
+type sliceSource []TelemetryPacket
+
+func (s sliceSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	for _, p := range s {
+		select {
+		case out <- p:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func drain(t *testing.T, s Source) {
+	t.Helper()
+	out := make(chan TelemetryPacket)
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background(), out) }()
+	for {
+		select {
+		case <-out:
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+}
+
+func TestConvertCapture(t *testing.T) {
+	dir := t.TempDir()
+	packets := []TelemetryPacket{
+		{Signal: -71, Timestamp: 1000, Pitch: 88.5, Altitude: 120.5, State: LAUNCHING, LoopsPerSecond: 10},
+		{Signal: -80, Timestamp: 1500, Altitude: 90, State: DESCENDING, LoopsPerSecond: 10},
+	}
+	src := filepath.Join(dir, "flight.log")
+	if err := os.WriteFile(src, marshalCapture(packets, models.TimeUnitSeconds), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	parser := models.DefaultPluginSettings().Parser
+	parser.TimeUnit = models.TimeUnitSeconds
+
+	col, back := filepath.Join(dir, "flight.col"), filepath.Join(dir, "back.log")
+	if err := ConvertCapture(src, col, models.FormatColumnar, parser); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(col)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if got, err := ReadColumnar(f); err != nil || len(got) != 2 || got[1].Timestamp != 1500 {
+		t.Errorf("columnar capture holds %+v, %v; want the packets in milliseconds", got, err)
+	}
+
+	if err := ConvertCapture(col, back, models.FormatCSV, parser); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(src)
+	if got, _ := os.ReadFile(back); string(got) != string(want) {
+		t.Errorf("round trip through columnar gave\n%s\nwant\n%s", got, want)
+	}
+	if err := ConvertCapture(src, col, "xml", parser); err == nil {
+		t.Error("want an error for an unknown format")
+	}
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+)
+
+// newResourceHandler routes the datasource's resource endpoints, each behind
+// its own rate limit.
+func (d *Datasource) newResourceHandler() backend.CallResourceHandler {
+	limiter := newRateLimiter(d.settings.RateLimit)
+	mux := http.NewServeMux()
+
+	handle := func(method, path string, h http.HandlerFunc) {
+		mux.Handle(method+" "+path, limiter.wrap(path, h))
+	}
+
+	handle(http.MethodGet, "/latest", d.handleLatest)
+	handle(http.MethodGet, "/landing/zone", d.handleLandingZone)
+	handle(http.MethodGet, "/fields", handleFields)
+	handle(http.MethodGet, "/schema", handleSchema)
+	handle(http.MethodGet, "/errors", d.handleErrors)
+	handle(http.MethodDelete, "/errors", d.handleResetErrors)
+	handle(http.MethodGet, "/sim", d.handleSimStatus)
+	handle(http.MethodPost, "/sim", d.handleSimControl)
+	handle(http.MethodGet, "/sim/config", d.handleSimConfig)
+	handle(http.MethodGet, "/sim/presets", handleSimPresets)
+	handle(http.MethodPost, "/sim/{action}", d.handleSimAction)
+	handle(http.MethodGet, "/flights/stats", d.handleFlightStats)
+	handle(http.MethodGet, "/flight/{file}", d.handleFlightExport)
+
+	return httpadapter.New(mux)
+}
+
+// CallResource implements backend.CallResourceHandler.
+func (d *Datasource) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return d.resources.CallResource(ctx, req, sender)
+}
+
+// handleLatest returns the most recent packet seen by any stream.
+func (d *Datasource) handleLatest(w http.ResponseWriter, _ *http.Request) {
+	packet, ok := d.latestPacket()
+	if !ok {
+		http.Error(w, "no telemetry received yet", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, packet)
+}
+
+// handleFields lists the fields a query can request and their unit choices.
+func handleFields(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, fieldRegistry)
+}
+
+// handleSchema returns per-field type and unit metadata keyed by field name.
+func handleSchema(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{"fields": registrySchema()})
+}
+
+// handleErrors returns the most recent parse errors, newest first. The
+// optional limit parameter caps how many are returned.
+func (d *Datasource) handleErrors(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	writeJSON(w, d.parseErrors.Recent(limit))
+}
+
+func (d *Datasource) handleResetErrors(w http.ResponseWriter, _ *http.Request) {
+	d.parseErrors.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.DefaultLogger.Error("Failed to write resource response", "error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package plugin
+
+// rollingWindow keeps the sum of the last n values added in a ring buffer,
+// for rolling statistics over received packets.
+type rollingWindow struct {
+	values []float64
+	next   int
+	filled int
+	sum    float64
+}
+
+func newRollingWindow(n int) *rollingWindow {
+	return &rollingWindow{values: make([]float64, max(n, 1))}
+}
+
+// Add pushes v into the window. Once it is full the oldest value is evicted
+// and returned, with ok set.
+func (w *rollingWindow) Add(v float64) (evicted float64, ok bool) {
+	evicted, ok = w.values[w.next], w.filled == len(w.values)
+	w.sum += v - evicted
+	w.values[w.next] = v
+	w.next = (w.next + 1) % len(w.values)
+	w.filled = min(w.filled+1, len(w.values))
+	return evicted, ok
+}
+
+func (w *rollingWindow) Sum() float64 { return w.sum }
+
+// Len is the number of values in the window.
+func (w *rollingWindow) Len() int { return w.filled }
+
+// Mean is the average of the values in the window, 0 while it is empty.
+func (w *rollingWindow) Mean() float64 {
+	if w.filled == 0 {
+		return 0
+	}
+	return w.sum / float64(w.filled)
+}
+
+func (w *rollingWindow) Reset() {
+	clear(w.values)
+	w.next, w.filled, w.sum = 0, 0, 0
+}
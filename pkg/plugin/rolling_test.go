@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestRollingWindow(t *testing.T) {
+	w := newRollingWindow(3)
+	if w.Mean() != 0 {
+		t.Errorf("empty window mean %v, want 0", w.Mean())
+	}
+	for i, tc := range []struct{ add, mean float64 }{
+		{add: 3, mean: 3},
+		{add: 6, mean: 4.5},
+		{add: 9, mean: 6},
+		{add: 12, mean: 9}, // 3 evicted
+	} {
+		w.Add(tc.add)
+		if w.Mean() != tc.mean {
+			t.Errorf("step %d: mean %v, want %v", i, w.Mean(), tc.mean)
+		}
+	}
+	if evicted, ok := w.Add(15); !ok || evicted != 6 {
+		t.Errorf("full window evicted %v, %v, want 6, true", evicted, ok)
+	}
+	w.Reset()
+	if w.Len() != 0 || w.Sum() != 0 {
+		t.Errorf("reset window holds %d values summing to %v", w.Len(), w.Sum())
+	}
+}
+
+func TestGForceAvgSmoothsSpikes(t *testing.T) {
+	q := defaultQuery()
+	q.Fields = []string{"gforce", "gforceAvg"}
+	q.GForceWindow = 4
+	b := newFrameBuilder(q, models.DefaultPluginSettings())
+
+	var avg float64
+	for _, g := range []float64{1, 1, 1, 21} {
+		frame := b.Build(TelemetryPacket{GForce: g})
+		avg = frame.Fields[2].At(0).(float64)
+	}
+	if avg != 6 {
+		t.Errorf("got rolling average %v over a 21g spike, want 6", avg)
+	}
+}
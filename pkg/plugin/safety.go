@@ -0,0 +1,39 @@
+package plugin
+
+import "github.com/dibes/rocket-telemtry/pkg/models"
+
+// SafetyFlags is the result of a single pass over the range-safety envelope.
+type SafetyFlags struct {
+	OverGForce      bool
+	DescentWarning  bool
+	CeilingExceeded bool
+	SignalCritical  bool
+	GPSLost         bool
+}
+
+// CheckSafety evaluates a packet against the configured envelope. verticalSpeed
+// is in m/s, positive upwards.
+func CheckSafety(packet TelemetryPacket, verticalSpeed float64, cfg models.SafetyConfig) SafetyFlags {
+	return SafetyFlags{
+		OverGForce:      packet.GForce > cfg.MaxGForce,
+		DescentWarning:  -verticalSpeed > cfg.MaxDescentRate,
+		CeilingExceeded: packet.Altitude > cfg.Ceiling,
+		SignalCritical:  packet.Signal < cfg.MinSignal,
+		GPSLost:         gpsLost(packet),
+	}
+}
+
+// gpsLost reports a packet without a position: a receiver without a fix says
+// so, or reports the null island.
+func gpsLost(packet TelemetryPacket) bool {
+	return packet.GPSNoFix || (packet.GPS.Latitude == 0 && packet.GPS.Longitude == 0)
+}
+
+// verticalSpeed derives the climb rate in m/s between two packets.
+func verticalSpeed(prev, curr TelemetryPacket) float64 {
+	dt := (curr.Timestamp - prev.Timestamp) / 1000
+	if dt <= 0 {
+		return 0
+	}
+	return (curr.Altitude - prev.Altitude) / dt
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestCheckSafety(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Safety
+	nominal := TelemetryPacket{
+		Signal:   -60,
+		GForce:   1,
+		Altitude: 100,
+		GPS:      GPS{Latitude: 37.7749, Longitude: -122.4194},
+	}
+
+	if flags := CheckSafety(nominal, -5, cfg); flags != (SafetyFlags{}) {
+		t.Fatalf("nominal packet raised flags: %+v", flags)
+	}
+
+	bad := nominal
+	bad.GForce = cfg.MaxGForce + 1
+	bad.Altitude = cfg.Ceiling + 1
+	bad.Signal = cfg.MinSignal - 1
+	bad.GPS = GPS{}
+
+	flags := CheckSafety(bad, -(cfg.MaxDescentRate + 1), cfg)
+	want := SafetyFlags{
+		OverGForce:      true,
+		DescentWarning:  true,
+		CeilingExceeded: true,
+		SignalCritical:  true,
+		GPSLost:         true,
+	}
+	if flags != want {
+		t.Fatalf("got %+v, want %+v", flags, want)
+	}
+}
+
+func TestVerticalSpeed(t *testing.T) {
+	prev := TelemetryPacket{Timestamp: 1000, Altitude: 100}
+	curr := TelemetryPacket{Timestamp: 1500, Altitude: 90}
+
+	if got := verticalSpeed(prev, curr); got != -20 {
+		t.Fatalf("got %v, want -20", got)
+	}
+	if got := verticalSpeed(curr, curr); got != 0 {
+		t.Fatalf("zero dt: got %v, want 0", got)
+	}
+}
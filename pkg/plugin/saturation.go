@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"math"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// saturationDetector flags an altimeter stuck at the top of its range: the
+// reading sits at the configured maximum for several consecutive packets
+// while the rocket is still climbing.
+type saturationDetector struct {
+	cfg    models.SaturationConfig
+	pinned int
+}
+
+// Update counts the packet towards the run of pinned readings and reports
+// whether the run is long enough to flag saturation.
+func (d *saturationDetector) Update(packet TelemetryPacket) bool {
+	if d.cfg.Altitude <= 0 {
+		return false
+	}
+	if math.Abs(packet.Altitude-d.cfg.Altitude) <= d.cfg.Tolerance && climbing(packet) {
+		d.pinned++
+	} else {
+		d.pinned = 0
+	}
+	return d.pinned >= max(d.cfg.Packets, 1)
+}
+
+// climbing reports whether the packet says the rocket is ascending. A pinned
+// altimeter reads no climb of its own, so this trusts the reported velocity,
+// or the flight state when there is none.
+func climbing(packet TelemetryPacket) bool {
+	if packet.Velocity != nil {
+		return *packet.Velocity > 0
+	}
+	return packet.State == LAUNCHING
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestSaturationDetector(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Saturation
+	cfg.Altitude = 1000
+	up, down := 50.0, -5.0
+
+	tests := []struct {
+		name     string
+		altitude float64
+		velocity *float64
+		state    RocketState
+		want     bool
+	}{
+		{"climbing below the limit", 950, &up, LAUNCHING, false},
+		{"first pinned reading", 1000, &up, LAUNCHING, false},
+		{"second pinned reading", 999.8, &up, LAUNCHING, false},
+		{"third pinned reading", 1000, &up, LAUNCHING, true},
+		{"still pinned", 1000, nil, LAUNCHING, true},
+		{"pinned while descending", 1000, &down, DESCENDING, false},
+		{"pinned again", 1000, nil, LAUNCHING, false},
+	}
+	d := saturationDetector{cfg: cfg}
+	for _, tt := range tests {
+		packet := TelemetryPacket{Altitude: tt.altitude, Velocity: tt.velocity, State: tt.state}
+		if got := d.Update(packet); got != tt.want {
+			t.Errorf("%s: saturated = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSaturationDisabled(t *testing.T) {
+	d := saturationDetector{cfg: models.DefaultPluginSettings().Saturation}
+	for range 10 {
+		if d.Update(TelemetryPacket{Altitude: 0, State: LAUNCHING}) {
+			t.Fatal("saturation flagged without a configured altitude")
+		}
+	}
+}
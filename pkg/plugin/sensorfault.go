@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// sensorValues maps the sensor names a failure can target to their reading
+// in a packet.
+var sensorValues = map[string]func(*TelemetryPacket) *float64{
+	"altitude":  func(p *TelemetryPacket) *float64 { return &p.Altitude },
+	"pitch":     func(p *TelemetryPacket) *float64 { return &p.Pitch },
+	"roll":      func(p *TelemetryPacket) *float64 { return &p.Roll },
+	"yaw":       func(p *TelemetryPacket) *float64 { return &p.Yaw },
+	"gforce":    func(p *TelemetryPacket) *float64 { return &p.GForce },
+	"latitude":  func(p *TelemetryPacket) *float64 { return &p.GPS.Latitude },
+	"longitude": func(p *TelemetryPacket) *float64 { return &p.GPS.Longitude },
+}
+
+// sensorFailure wraps a simulator and kills one sensor a set time into each
+// flight, leaving the others reporting normally. The sensor recovers once the
+// rocket is back on the pad.
+type sensorFailure struct {
+	sim      Simulator
+	cfg      models.SensorFailureConfig
+	value    func(*TelemetryPacket) *float64
+	launched time.Time
+	stuck    *float64
+}
+
+func newSensorFailure(sim Simulator, cfg models.SensorFailureConfig) (*sensorFailure, error) {
+	value, ok := sensorValues[cfg.Field]
+	if !ok {
+		return nil, fmt.Errorf("sensor failure: unknown field %q", cfg.Field)
+	}
+	switch cfg.Mode {
+	case models.SensorZero, models.SensorStuck:
+	default:
+		return nil, fmt.Errorf("sensor failure: unknown mode %q", cfg.Mode)
+	}
+	return &sensorFailure{sim: sim, cfg: cfg, value: value}, nil
+}
+
+func (s *sensorFailure) Unwrap() Simulator { return s.sim }
+
+func (s *sensorFailure) TickAt(now time.Time) TelemetryPacket {
+	p := s.sim.TickAt(now)
+
+	switch {
+	case p.State == LANDED:
+		s.launched, s.stuck = time.Time{}, nil
+		return p
+	case s.launched.IsZero():
+		s.launched = now
+	}
+	if now.Sub(s.launched).Seconds() < s.cfg.After {
+		return p
+	}
+
+	v := s.value(&p)
+	if s.cfg.Mode == models.SensorStuck {
+		if s.stuck == nil {
+			held := *v
+			s.stuck = &held
+		}
+		*v = *s.stuck
+	} else {
+		*v = 0
+	}
+	p.SensorFailed = true
+	return p
+}
+
+// rocketSimulation finds the physics simulation beneath any wrappers.
+func rocketSimulation(sim Simulator) (*RocketSimulation, bool) {
+	for {
+		switch s := sim.(type) {
+		case *RocketSimulation:
+			return s, true
+		case interface{ Unwrap() Simulator }:
+			sim = s.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestSensorFailure(t *testing.T) {
+	for _, mode := range []string{models.SensorZero, models.SensorStuck} {
+		cfg := models.DefaultPluginSettings().Simulation
+		cfg.SensorFailure = models.SensorFailureConfig{Field: "altitude", Mode: mode, After: 3}
+
+		start := time.UnixMilli(0)
+		sim, err := newSimulator(start, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := rocketSimulation(sim); !ok {
+			t.Fatal("wrapped simulation is not reachable for control")
+		}
+
+		var launchedAt float64
+		var stuck *float64
+		for now := start; now.Sub(start) < 20*time.Second; now = now.Add(500 * time.Millisecond) {
+			p := sim.TickAt(now)
+			if p.State == LANDED {
+				continue
+			}
+			if launchedAt == 0 {
+				launchedAt = p.Timestamp
+			}
+			failed := p.Timestamp-launchedAt >= 3000
+			if p.SensorFailed != failed {
+				t.Fatalf("%s: sensorFailed = %v %vms after launch", mode, p.SensorFailed, p.Timestamp-launchedAt)
+			}
+			if p.Pitch == 0 {
+				t.Fatalf("%s: other sensors affected", mode)
+			}
+			if !failed {
+				continue
+			}
+			switch {
+			case mode == models.SensorZero && p.Altitude != 0:
+				t.Fatalf("zeroed altitude reads %v", p.Altitude)
+			case mode == models.SensorStuck && stuck == nil:
+				stuck = &p.Altitude
+			case mode == models.SensorStuck && p.Altitude != *stuck:
+				t.Fatalf("stuck altitude changed from %v to %v", *stuck, p.Altitude)
+			}
+		}
+		if mode == models.SensorStuck && (stuck == nil || *stuck == 0) {
+			t.Fatal("stuck altitude never held a flight value")
+		}
+	}
+}
+
+func TestSensorFailureUnknownField(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.SensorFailure.Field = "humidity"
+	if _, err := newSimulator(time.Now(), cfg); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+}
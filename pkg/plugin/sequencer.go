@@ -0,0 +1,65 @@
+package plugin
+
+// phaseRank orders the states along a flight. Pad states share a rank.
+var phaseRank = map[RocketState]int{
+	LANDED:      0,
+	CALIBRATION: 0,
+	IGNITION:    1,
+	LAUNCHING:   2,
+	APEX:        3,
+	DESCENDING:  4,
+}
+
+// stateSequencer turns a noisy state series into one that only moves forward
+// through the flight: LANDED, LAUNCHING, APEX, DESCENDING and back to LANDED.
+// States may be skipped, but a backward transition is ignored unless the
+// telemetry keeps reporting it for resetAfter packets in a row, which is taken
+// as a reset such as a recycled countdown or a restarted replay.
+type stateSequencer struct {
+	resetAfter int
+	state      RocketState
+	started    bool
+	pending    RocketState
+	pendingFor int
+}
+
+func (s *stateSequencer) Next(state RocketState) RocketState {
+	if !s.started {
+		s.state, s.started = state, true
+		return state
+	}
+
+	if state == UNKNOWN {
+		return s.state // hold through unparseable states
+	}
+	if s.forward(state) {
+		s.state, s.pendingFor = state, 0
+		return state
+	}
+	if state == s.state {
+		s.pendingFor = 0
+		return state
+	}
+
+	if state != s.pending {
+		s.pending, s.pendingFor = state, 0
+	}
+	s.pendingFor++
+	if s.pendingFor >= s.resetAfter {
+		s.state, s.pendingFor = state, 0
+	}
+	return s.state
+}
+
+// forward reports whether moving to next follows the flight sequence.
+func (s *stateSequencer) forward(next RocketState) bool {
+	from, ok := phaseRank[s.state]
+	to, known := phaseRank[next]
+	if !ok || !known {
+		return true // nothing to order unknown states against
+	}
+	if from == phaseRank[DESCENDING] && to == phaseRank[LANDED] {
+		return true // touchdown completes the cycle
+	}
+	return to > from || (to == from && next != s.state)
+}
@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStateSequencer(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		in, want []RocketState
+	}{
+		{
+			name: "clean flight",
+			in:   []RocketState{LANDED, LAUNCHING, APEX, DESCENDING, LANDED},
+			want: []RocketState{LANDED, LAUNCHING, APEX, DESCENDING, LANDED},
+		},
+		{
+			name: "backward glitches ignored",
+			in:   []RocketState{LANDED, LAUNCHING, LANDED, LAUNCHING, APEX, LAUNCHING, DESCENDING, APEX, DESCENDING},
+			want: []RocketState{LANDED, LAUNCHING, LAUNCHING, LAUNCHING, APEX, APEX, DESCENDING, DESCENDING, DESCENDING},
+		},
+		{
+			name: "unknown states held",
+			in:   []RocketState{LAUNCHING, UNKNOWN, UNKNOWN, UNKNOWN, APEX},
+			want: []RocketState{LAUNCHING, LAUNCHING, LAUNCHING, LAUNCHING, APEX},
+		},
+		{
+			name: "missed apex skipped",
+			in:   []RocketState{LAUNCHING, DESCENDING, LANDED},
+			want: []RocketState{LAUNCHING, DESCENDING, LANDED},
+		},
+		{
+			name: "sustained backward state is a reset",
+			in:   []RocketState{LAUNCHING, LANDED, LANDED, LANDED, LAUNCHING},
+			want: []RocketState{LAUNCHING, LAUNCHING, LAUNCHING, LANDED, LAUNCHING},
+		},
+	} {
+		s := &stateSequencer{resetAfter: 3}
+		var got []RocketState
+		for _, state := range tc.in {
+			got = append(got, s.Next(state))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+const (
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// serialBauds are the baud rates a serial port can be opened at.
+var serialBauds = []int{9600, 19200, 38400, 57600, 115200, 230400, 460800, 921600}
+
+// serialPort is the part of an open serial device the reader needs.
+type serialPort interface {
+	io.ReadCloser
+	SetReadDeadline(t time.Time) error
+}
+
+// serialSource reads radio packet lines from a serial device. Every read is
+// bounded by readTimeout so a hung adapter cannot wedge the stream; after
+// maxMissed consecutive timeouts the port is reopened with backoff.
+type serialSource struct {
+	device      string
+	readTimeout time.Duration
+	maxMissed   int
+	parser      *PacketParser
+	errs        *parseErrorLog
+	open        func() (serialPort, error)
+}
+
+func newSerialSource(cfg models.SerialConfig, parserCfg models.ParserConfig, errs *parseErrorLog) (*serialSource, error) {
+	cfg, err := serialConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	parser, err := NewPacketParser(parserCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &serialSource{
+		device:      cfg.Device,
+		readTimeout: time.Duration(cfg.ReadTimeout * float64(time.Second)),
+		maxMissed:   cfg.MaxMissed,
+		parser:      parser,
+		errs:        errs,
+		open:        func() (serialPort, error) { return openSerialPort(cfg) },
+	}, nil
+}
+
+// serialConfig fills in the defaults of a serial config and checks that its
+// baud rate is supported and its line settings describe a frame a UART can
+// send.
+func serialConfig(cfg models.SerialConfig) (models.SerialConfig, error) {
+	if cfg.Device == "" {
+		return cfg, fmt.Errorf("serial source requires a device")
+	}
+	defaults := models.DefaultSerialConfig()
+	if cfg.Baud <= 0 {
+		cfg.Baud = defaults.Baud
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = defaults.ReadTimeout
+	}
+	if cfg.MaxMissed <= 0 {
+		cfg.MaxMissed = defaults.MaxMissed
+	}
+	if cfg.DataBits == 0 {
+		cfg.DataBits = defaults.DataBits
+	}
+	if cfg.Parity == "" {
+		cfg.Parity = defaults.Parity
+	}
+	if cfg.StopBits == 0 {
+		cfg.StopBits = defaults.StopBits
+	}
+	if cfg.FlowControl == "" {
+		cfg.FlowControl = defaults.FlowControl
+	}
+
+	if !slices.Contains(serialBauds, cfg.Baud) {
+		return cfg, fmt.Errorf("unsupported baud rate %d", cfg.Baud)
+	}
+	if cfg.DataBits < 5 || cfg.DataBits > 8 {
+		return cfg, fmt.Errorf("serial data bits must be 5 to 8, got %d", cfg.DataBits)
+	}
+	switch cfg.Parity {
+	case models.ParityNone, models.ParityEven, models.ParityOdd:
+	default:
+		return cfg, fmt.Errorf("unknown serial parity %q", cfg.Parity)
+	}
+	if cfg.StopBits != 1 && cfg.StopBits != 2 {
+		return cfg, fmt.Errorf("serial stop bits must be 1 or 2, got %d", cfg.StopBits)
+	}
+	// UARTs send 1.5 stop bits when asked for 2 with 5 data bits.
+	if cfg.StopBits == 2 && cfg.DataBits == 5 {
+		return cfg, fmt.Errorf("serial stop bits must be 1 with 5 data bits")
+	}
+	switch cfg.FlowControl {
+	case models.FlowNone, models.FlowRTSCTS:
+	case models.FlowXonXoff:
+		// XON and XOFF are ASCII control characters, which 5 and 6 bit
+		// frames cannot carry.
+		if cfg.DataBits < 7 {
+			return cfg, fmt.Errorf("xonxoff flow control needs at least 7 data bits, got %d", cfg.DataBits)
+		}
+	default:
+		return cfg, fmt.Errorf("unknown serial flow control %q", cfg.FlowControl)
+	}
+	return cfg, nil
+}
+
+func (s *serialSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	backoff := minReconnectBackoff
+	for {
+		port, err := s.open()
+		if err == nil {
+			var received bool
+			received, err = s.read(ctx, port, out)
+			port.Close()
+			if received {
+				backoff = minReconnectBackoff
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.DefaultLogger.Warn("Serial port unavailable, reconnecting", "device", s.device, "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxReconnectBackoff)
+	}
+}
+
+// read forwards packets from port until it fails or goes quiet for too long.
+// It reports whether any packet was received.
+func (s *serialSource) read(ctx context.Context, port serialPort, out chan<- TelemetryPacket) (bool, error) {
+	// Closing the port unblocks a pending read on cancellation.
+	stop := context.AfterFunc(ctx, func() { port.Close() })
+	defer stop()
+
+	reader := bufio.NewReader(port)
+	received := false
+	missed := 0
+	var partial string
+
+	for {
+		if err := port.SetReadDeadline(time.Now().Add(s.readTimeout)); err != nil {
+			return received, err
+		}
+		line, err := reader.ReadString('\n')
+		partial += line
+
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			missed++
+			log.DefaultLogger.Debug("Serial read timed out", "device", s.device, "missed", missed)
+			if missed >= s.maxMissed {
+				return received, fmt.Errorf("no data for %d consecutive reads", missed)
+			}
+			continue
+		}
+		if err != nil {
+			return received, err
+		}
+
+		missed = 0
+		text := strings.TrimSpace(partial)
+		partial = ""
+		if text == "" {
+			continue
+		}
+
+		packets, errs := s.parser.ParsePackets(text)
+		for _, err := range errs {
+			log.DefaultLogger.Debug("Skipping unparseable record", "line", text, "error", err)
+			s.errs.Record(err)
+		}
+		for _, packet := range packets {
+			received = true
+			select {
+			case out <- packet:
+			case <-ctx.Done():
+				return received, ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+	"golang.org/x/sys/unix"
+)
+
+// baudRates holds the termios flag of each of serialBauds.
+var baudRates = map[int]uint32{
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+	230400: unix.B230400,
+	460800: unix.B460800,
+	921600: unix.B921600,
+}
+
+var dataBits = map[int]uint32{5: unix.CS5, 6: unix.CS6, 7: unix.CS7, 8: unix.CS8}
+
+// openSerialPort opens the device in raw mode with the configured baud rate
+// and line settings, which serialConfig has validated. The file is opened
+// non-blocking so the runtime poller can honor read deadlines.
+func openSerialPort(cfg models.SerialConfig) (serialPort, error) {
+	device := cfg.Device
+	rate := baudRates[cfg.Baud]
+
+	f, err := os.OpenFile(device, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := f.SyscallConn()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	var termErr error
+	err = conn.Control(func(fd uintptr) {
+		t, err := unix.IoctlGetTermios(int(fd), unix.TCGETS)
+		if err != nil {
+			termErr = err
+			return
+		}
+		t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF | unix.INPCK
+		t.Oflag &^= unix.OPOST
+		t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+		t.Cflag &^= unix.CSIZE | unix.PARENB | unix.PARODD | unix.CSTOPB | unix.CRTSCTS | unix.CBAUD
+		t.Cflag |= dataBits[cfg.DataBits] | unix.CREAD | unix.CLOCAL | rate
+		switch cfg.Parity {
+		case models.ParityEven:
+			t.Cflag |= unix.PARENB
+			t.Iflag |= unix.INPCK
+		case models.ParityOdd:
+			t.Cflag |= unix.PARENB | unix.PARODD
+			t.Iflag |= unix.INPCK
+		}
+		if cfg.StopBits == 2 {
+			t.Cflag |= unix.CSTOPB
+		}
+		switch cfg.FlowControl {
+		case models.FlowRTSCTS:
+			t.Cflag |= unix.CRTSCTS
+		case models.FlowXonXoff:
+			t.Iflag |= unix.IXON | unix.IXOFF
+		}
+		t.Ispeed, t.Ospeed = rate, rate
+		termErr = unix.IoctlSetTermios(int(fd), unix.TCSETS, t)
+	})
+	if err == nil {
+		err = termErr
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("configure %s: %w", device, err)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package plugin
+
+import (
+	"errors"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// openSerialPort is only implemented on Linux.
+func openSerialPort(cfg models.SerialConfig) (serialPort, error) {
+	return nil, errors.New("serial sources are only supported on linux")
+}
@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// pipePort stands in for a serial device: reads on a pipe block until data is
+// written and honor read deadlines like a tty does.
+func pipePort(t *testing.T, opens *atomic.Int32) (func() (serialPort, error), chan *os.File) {
+	t.Helper()
+	writers := make(chan *os.File, 4)
+	return func() (serialPort, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { w.Close() })
+		opens.Add(1)
+		writers <- w
+		return r, nil
+	}, writers
+}
+
+func TestSerialSourceContinuesAfterReadTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var opens atomic.Int32
+	open, writers := pipePort(t, &opens)
+	src := &serialSource{device: "test", readTimeout: 20 * time.Millisecond, maxMissed: 100, parser: defaultParser, open: open}
+
+	packets := make(chan TelemetryPacket)
+	go src.Run(ctx, packets)
+	w := <-writers
+
+	w.WriteString(radioLine("10"))
+	if p := receive(t, packets); p.Altitude != 10 {
+		t.Fatalf("got altitude %v, want 10", p.Altitude)
+	}
+
+	// Let several reads time out, including one holding half a line.
+	w.WriteString("1000,90,0,0,1.0,")
+	time.Sleep(100 * time.Millisecond)
+	w.WriteString("20,37.7749,-122.4194,LAUNCHING,10\n")
+	if p := receive(t, packets); p.Altitude != 20 {
+		t.Fatalf("got altitude %v, want 20", p.Altitude)
+	}
+	if n := opens.Load(); n != 1 {
+		t.Fatalf("port opened %d times, want 1", n)
+	}
+}
+
+func TestSerialSourceReopensWhenSilent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var opens atomic.Int32
+	open, writers := pipePort(t, &opens)
+	src := &serialSource{device: "test", readTimeout: 10 * time.Millisecond, maxMissed: 2, parser: defaultParser, open: open}
+
+	packets := make(chan TelemetryPacket)
+	go src.Run(ctx, packets)
+	<-writers
+
+	select {
+	case w := <-writers:
+		w.WriteString(radioLine("30"))
+	case <-time.After(3 * time.Second):
+		t.Fatal("port was not reopened")
+	}
+	if p := receive(t, packets); p.Altitude != 30 {
+		t.Fatalf("got altitude %v, want 30", p.Altitude)
+	}
+}
+
+func TestSerialConfig(t *testing.T) {
+	cfg, err := serialConfig(models.SerialConfig{Device: "/dev/ttyUSB0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DataBits != 8 || cfg.Parity != models.ParityNone || cfg.StopBits != 1 || cfg.FlowControl != models.FlowNone {
+		t.Errorf("defaults = %d%s%d %s, want 8none1 none", cfg.DataBits, cfg.Parity, cfg.StopBits, cfg.FlowControl)
+	}
+
+	for _, tt := range []struct {
+		name string
+		cfg  models.SerialConfig
+		ok   bool
+	}{
+		{"7E1", models.SerialConfig{DataBits: 7, Parity: models.ParityEven}, true},
+		{"8O2 hardware flow", models.SerialConfig{Parity: models.ParityOdd, StopBits: 2, FlowControl: models.FlowRTSCTS}, true},
+		{"7N1 software flow", models.SerialConfig{DataBits: 7, FlowControl: models.FlowXonXoff}, true},
+		{"9 data bits", models.SerialConfig{DataBits: 9}, false},
+		{"mark parity", models.SerialConfig{Parity: "mark"}, false},
+		{"3 stop bits", models.SerialConfig{StopBits: 3}, false},
+		{"5 data bits, 2 stop bits", models.SerialConfig{DataBits: 5, StopBits: 2}, false},
+		{"6 data bits, software flow", models.SerialConfig{DataBits: 6, FlowControl: models.FlowXonXoff}, false},
+		{"unknown flow control", models.SerialConfig{FlowControl: "dtrdsr"}, false},
+		{"115200 baud", models.SerialConfig{Baud: 115200}, true},
+		{"12345 baud", models.SerialConfig{Baud: 12345}, false},
+	} {
+		tt.cfg.Device = "/dev/ttyUSB0"
+		if _, err := serialConfig(tt.cfg); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
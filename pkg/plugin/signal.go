@@ -0,0 +1,21 @@
+package plugin
+
+// SignalPercent maps an RSSI in dBm onto 0-100 across [minDBm, maxDBm],
+// clamping values outside the range.
+func SignalPercent(dbm int, minDBm, maxDBm float64) float64 {
+	if maxDBm <= minDBm {
+		return 0
+	}
+	percent := (float64(dbm) - minDBm) / (maxDBm - minDBm) * 100
+	return clamp(percent, 0, 100)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,25 @@
+package plugin
+
+import "testing"
+
+func TestSignalPercent(t *testing.T) {
+	tests := []struct {
+		dbm  int
+		want float64
+	}{
+		{-120, 0},
+		{-75, 50},
+		{-30, 100},
+		{-140, 0},
+		{-10, 100},
+	}
+	for _, tt := range tests {
+		if got := SignalPercent(tt.dbm, -120, -30); got != tt.want {
+			t.Errorf("SignalPercent(%d) = %v, want %v", tt.dbm, got, tt.want)
+		}
+	}
+
+	if got := SignalPercent(-50, -30, -120); got != 0 {
+		t.Errorf("inverted range: got %v, want 0", got)
+	}
+}
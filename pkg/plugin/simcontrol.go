@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// simRegistry tracks the simulations of running streams so they can be
+// controlled through the /sim endpoint.
+type simRegistry struct {
+	mu   sync.Mutex
+	sims []*RocketSimulation
+}
+
+func (r *simRegistry) Add(s *RocketSimulation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sims = append(r.sims, s)
+}
+
+func (r *simRegistry) Remove(s *RocketSimulation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, sim := range r.sims {
+		if sim == s {
+			r.sims = append(r.sims[:i], r.sims[i+1:]...)
+			return
+		}
+	}
+}
+
+// Each calls fn for every registered simulation.
+func (r *simRegistry) Each(fn func(*RocketSimulation)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sims {
+		fn(s)
+	}
+}
+
+// simStatus is the state of one running simulation as reported by /sim.
+type simStatus struct {
+	Armed    bool        `json:"armed"`
+	Paused   bool        `json:"paused"`
+	State    RocketState `json:"state"`
+	Altitude float64     `json:"altitude"`
+}
+
+func (s *RocketSimulation) status() simStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return simStatus{Armed: s.armed, Paused: s.paused, State: s.state, Altitude: s.altitude}
+}
+
+func (r *simRegistry) Status() []simStatus {
+	status := []simStatus{}
+	r.Each(func(s *RocketSimulation) {
+		status = append(status, s.status())
+	})
+	return status
+}
+
+// Configs returns the effective config of every running simulation.
+func (r *simRegistry) Configs() []models.SimulationConfig {
+	configs := []models.SimulationConfig{}
+	r.Each(func(s *RocketSimulation) {
+		configs = append(configs, s.Config())
+	})
+	return configs
+}
+
+type simCommand struct {
+	Action string `json:"action"`
+}
+
+// handleSimStatus reports the running simulations.
+func (d *Datasource) handleSimStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, d.sims.Status())
+}
+
+// handleSimConfig returns the effective config, including the seed, of every
+// running simulation.
+func (d *Datasource) handleSimConfig(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, d.sims.Configs())
+}
+
+// handleSimPresets lists the simulation presets for the config editor.
+func handleSimPresets(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, models.SimulationPresets)
+}
+
+// simActions are the control actions accepted by /sim, applied to every
+// running simulation.
+var simActions = map[string]func(*RocketSimulation){
+	"arm":    (*RocketSimulation).Arm,
+	"launch": (*RocketSimulation).Launch,
+	"abort":  (*RocketSimulation).Abort,
+	"reset":  func(s *RocketSimulation) { s.Reset(time.Now()) },
+	"pause":  (*RocketSimulation).Pause,
+	"resume": (*RocketSimulation).Resume,
+}
+
+// handleSimControl applies an action such as {"action": "arm"} to every
+// running simulation and reports their resulting status.
+func (d *Datasource) handleSimControl(w http.ResponseWriter, r *http.Request) {
+	var cmd simCommand
+	if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+		http.Error(w, "invalid command: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	d.applySimAction(w, cmd.Action)
+}
+
+// handleSimAction serves POST /sim/{action}, taking no body so it can be
+// wired straight to a dashboard button:
+//
+//	/sim/arm     release a RequireArming countdown, or restart an aborted one
+//	/sim/launch  ignite now, skipping the countdown
+//	/sim/abort   scrub the countdown, or deploy the parachute in flight
+//	/sim/reset   put the rocket back on the pad, disarmed
+//	/sim/pause   freeze the flight; the stream repeats the last packet
+//	/sim/resume  continue a paused flight
+//
+// The response is the resulting status of every running simulation, as
+// returned by GET /sim.
+func (d *Datasource) handleSimAction(w http.ResponseWriter, r *http.Request) {
+	d.applySimAction(w, r.PathValue("action"))
+}
+
+func (d *Datasource) applySimAction(w http.ResponseWriter, action string) {
+	apply, ok := simActions[action]
+	if !ok {
+		http.Error(w, "unknown action "+action, http.StatusBadRequest)
+		return
+	}
+	d.sims.Each(apply)
+	writeJSON(w, d.sims.Status())
+}
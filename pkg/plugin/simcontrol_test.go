@@ -0,0 +1,194 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestSimControlArm(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.RequireArming = true
+	sim := NewRocketSimulationAt(time.UnixMilli(0), cfg)
+
+	d := &Datasource{sims: &simRegistry{}}
+	d.sims.Add(sim)
+
+	rec := httptest.NewRecorder()
+	d.handleSimControl(rec, httptest.NewRequest(http.MethodPost, "/sim", strings.NewReader(`{"action":"arm"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+
+	var status []simStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || !status[0].Armed {
+		t.Fatalf("got status %+v, want one armed simulation", status)
+	}
+
+	rec = httptest.NewRecorder()
+	d.handleSimControl(rec, httptest.NewRequest(http.MethodPost, "/sim", strings.NewReader(`{"action":"warp"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown action: got status %d, want 400", rec.Code)
+	}
+}
+
+// TestSimControlConcurrentWithTicking is meant to be run with -race.
+func TestSimControlConcurrentWithTicking(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.RequireArming = true
+	start := time.UnixMilli(0)
+	sim := NewRocketSimulationAt(start, cfg)
+
+	d := &Datasource{sims: &simRegistry{}}
+	d.sims.Add(sim)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 2000; i++ {
+			sim.TickAt(start.Add(time.Duration(i) * 500 * time.Millisecond))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			rec := httptest.NewRecorder()
+			d.handleSimControl(rec, httptest.NewRequest(http.MethodPost, "/sim", strings.NewReader(`{"action":"arm"}`)))
+			d.handleSimStatus(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/sim", nil))
+			sim.Configure(cfg)
+			if i%50 == 0 {
+				sim.Reset(start)
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSimConfigReportsSeed(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.Seed = 42
+	d := &Datasource{sims: &simRegistry{}}
+	d.sims.Add(NewRocketSimulation(cfg))
+
+	rec := httptest.NewRecorder()
+	d.handleSimConfig(rec, httptest.NewRequest(http.MethodGet, "/sim/config", nil))
+
+	var configs []models.SimulationConfig
+	if err := json.NewDecoder(rec.Body).Decode(&configs); err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Seed != 42 {
+		t.Fatalf("got %+v, want one config with seed 42", configs)
+	}
+}
+
+func postSimAction(t *testing.T, d *Datasource, action string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/sim/"+action, nil)
+	req.SetPathValue("action", action)
+	rec := httptest.NewRecorder()
+	d.handleSimAction(rec, req)
+	return rec
+}
+
+func TestSimActionRoutes(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.Seed = 1
+	cfg.HangFireProbability = 0
+	cfg.AbortProbability = 0
+	start := time.Now()
+	sim := NewRocketSimulationAt(start, cfg)
+	sim.TickAt(start)
+
+	d := &Datasource{sims: &simRegistry{}}
+	d.sims.Add(sim)
+
+	rec := postSimAction(t, d, "launch")
+	var status []simStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || status[0].State != LAUNCHING {
+		t.Fatalf("launch: got %+v, want LAUNCHING", status)
+	}
+
+	now := start
+	tick := func(n int) TelemetryPacket {
+		var p TelemetryPacket
+		for i := 0; i < n; i++ {
+			now = now.Add(500 * time.Millisecond)
+			p = sim.TickAt(now)
+		}
+		return p
+	}
+
+	climbing := tick(4)
+	postSimAction(t, d, "pause")
+	paused := tick(10)
+	if paused.Altitude != climbing.Altitude || paused.State != climbing.State {
+		t.Fatalf("paused: altitude %v state %v, want frozen at %v %v", paused.Altitude, paused.State, climbing.Altitude, climbing.State)
+	}
+	postSimAction(t, d, "resume")
+	if resumed := tick(1); resumed.Altitude <= climbing.Altitude {
+		t.Fatalf("resumed: altitude %v, want climbing past %v", resumed.Altitude, climbing.Altitude)
+	}
+
+	postSimAction(t, d, "abort")
+	if p := tick(1); p.State != DESCENDING {
+		t.Fatalf("abort in flight: got state %v, want DESCENDING", p.State)
+	}
+
+	postSimAction(t, d, "reset")
+	postSimAction(t, d, "abort")
+	if got := sim.status(); got.State != LANDED || got.Armed {
+		t.Fatalf("abort on the pad: got %+v, want disarmed on the pad", got)
+	}
+
+	if rec := postSimAction(t, d, "warp"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown action: got status %d, want 400", rec.Code)
+	}
+}
+
+func TestSimArmAfterScrub(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.Seed = 1
+	cfg.AbortProbability = 1
+	cfg.RetryDelay = 0
+	start := time.UnixMilli(0)
+	sim := NewRocketSimulationAt(start, cfg)
+
+	d := &Datasource{sims: &simRegistry{}}
+	d.sims.Add(sim)
+
+	now := start
+	tick := func(n int) TelemetryPacket {
+		var p TelemetryPacket
+		for i := 0; i < n; i++ {
+			now = now.Add(500 * time.Millisecond)
+			p = sim.TickAt(now)
+		}
+		return p
+	}
+
+	// Scrubbed, the countdown holds on the pad past the launch delay.
+	if p := tick(20); p.State != LANDED {
+		t.Fatalf("scrubbed countdown: got state %v, want LANDED", p.State)
+	}
+
+	cfg.AbortProbability = 0
+	sim.Configure(cfg)
+	postSimAction(t, d, "arm")
+	if p := tick(12); p.State != LAUNCHING {
+		t.Fatalf("re-armed after a scrub: got state %v, want LAUNCHING", p.State)
+	}
+}
@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"math"
+	"time"
+)
+
+// gpsReceiver models the simulated GPS receiver, which may lose its fix and
+// optionally dead-reckon from the last known velocity while it is gone.
+type gpsReceiver struct {
+	dropoutUntil time.Time
+	lastFix      GPS
+	lastFixAt    time.Time
+	velocity     GPS // degrees per second from the last two fixes
+	hasFix       bool
+}
+
+// report returns the position the receiver reports for the true position at
+// now, and whether it has a fix and is dead reckoning.
+func (s *RocketSimulation) reportGPS(now time.Time, truth GPS, dt float64) (GPS, bool, bool) {
+	r := &s.gps
+	cfg := s.cfg
+
+	// A miscalibrated receiver is off by the same distance and direction on
+	// every fix.
+	if cfg.GPSBias != 0 {
+		az := toRadians(cfg.GPSBiasBearing)
+		truth.Latitude, truth.Longitude = offsetLatLon(truth.Latitude, truth.Longitude, cfg.GPSBias*math.Cos(az), cfg.GPSBias*math.Sin(az))
+	}
+
+	if now.After(r.dropoutUntil) && cfg.GPSDropoutRate > 0 && s.rng.Float64() < cfg.GPSDropoutRate*dt {
+		r.dropoutUntil = now.Add(time.Duration(cfg.GPSDropoutDuration * float64(time.Second)))
+	}
+
+	if now.Before(r.dropoutUntil) {
+		if !cfg.DeadReckoning || !r.hasFix {
+			return GPS{}, false, false
+		}
+		since := now.Sub(r.lastFixAt).Seconds()
+		return GPS{
+			Latitude:  r.lastFix.Latitude + r.velocity.Latitude*since,
+			Longitude: r.lastFix.Longitude + r.velocity.Longitude*since,
+		}, false, true
+	}
+
+	// Fix (re)acquired: report the truth, snapping back from any estimate.
+	if r.hasFix {
+		if elapsed := now.Sub(r.lastFixAt).Seconds(); elapsed > 0 {
+			r.velocity = GPS{
+				Latitude:  (truth.Latitude - r.lastFix.Latitude) / elapsed,
+				Longitude: (truth.Longitude - r.lastFix.Longitude) / elapsed,
+			}
+		}
+	}
+	r.lastFix, r.lastFixAt, r.hasFix = truth, now, true
+	return truth, true, false
+}
@@ -0,0 +1,205 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// Source produces telemetry packets for a stream. Run blocks, writing packets
+// to out until the context is cancelled or the source is exhausted.
+type Source interface {
+	Run(ctx context.Context, out chan<- TelemetryPacket) error
+}
+
+// newSource builds the packet source configured on the datasource for a
+// stream query, failing over between sources when several are configured,
+// recorded when a recording is configured and capped at the maximum packet
+// rate.
+func (d *Datasource) newSource(q Query) (Source, error) {
+	src, err := d.newFailoverSource(q)
+	if err != nil {
+		return nil, err
+	}
+	if d.recorder != nil {
+		src = &recordingSource{source: src, rec: d.recorder}
+	}
+	if d.settings.MaxPacketRate <= 0 {
+		return src, nil
+	}
+	interval := time.Duration(float64(time.Second) / d.settings.MaxPacketRate)
+	return &cappedSource{source: src, interval: interval, now: time.Now}, nil
+}
+
+func (d *Datasource) newFailoverSource(q Query) (Source, error) {
+	cfgs := d.settings.SourceConfigs()
+	if len(cfgs) == 1 {
+		return d.newSingleSource(cfgs[0], q)
+	}
+
+	f := &failoverSource{now: time.Now}
+	for i, cfg := range cfgs {
+		src, err := d.newSingleSource(cfg, q)
+		if err != nil {
+			return nil, fmt.Errorf("source %d: %w", i, err)
+		}
+		name := sourceName(cfg, i)
+		staleAfter := time.Duration(cfg.StaleAfter * float64(time.Second))
+		if staleAfter <= 0 {
+			staleAfter = defaultStaleAfter
+		}
+		f.sources = append(f.sources, prioritizedSource{name: name, source: src, staleAfter: staleAfter})
+	}
+	return f, nil
+}
+
+// sourceName is the name of the i-th configured source, as carried by the
+// packets it delivers.
+func sourceName(cfg models.SourceConfig, i int) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return fmt.Sprintf("%s-%d", cfg.Type, i)
+}
+
+// newSingleSource builds one source. Simulations register with the
+// datasource for control and lines that fail to parse are recorded for /errors.
+func (d *Datasource) newSingleSource(cfg models.SourceConfig, q Query) (Source, error) {
+	switch cfg.Type {
+	case "", models.SourceSimulation:
+		cfg, err := d.simulationConfig(q)
+		if err != nil {
+			return nil, err
+		}
+		return &simulationSource{interval: 500 * time.Millisecond, cfg: cfg, sims: d.sims}, nil
+	case models.SourceFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("file source requires a file path")
+		}
+		parser, err := NewPacketParser(d.settings.Parser)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Follow && cfg.Reverse {
+			return nil, fmt.Errorf("file source cannot follow and reverse at once")
+		}
+		return &fileSource{path: cfg.File, follow: cfg.Follow, reverse: cfg.Reverse, parser: parser, errs: d.parseErrors, pollInterval: 250 * time.Millisecond}, nil
+	case models.SourceSerial:
+		return newSerialSource(cfg.Serial, d.settings.Parser, d.parseErrors)
+	default:
+		return nil, fmt.Errorf("unknown source %q", cfg.Type)
+	}
+}
+
+// simulationConfig is the datasource's simulation config with the query's
+// seed, if it has one, in place of the configured one, for the query's rocket
+// of the formation.
+func (d *Datasource) simulationConfig(q Query) (models.SimulationConfig, error) {
+	cfg := d.settings.Simulation
+	if q.Seed != nil {
+		cfg.Seed = *q.Seed
+	}
+	return formationMember(cfg, q.Rocket)
+}
+
+// Simulator generates a simulated packet for each tick.
+type Simulator interface {
+	TickAt(now time.Time) TelemetryPacket
+}
+
+// newSimulator returns the trajectory replay when a trajectory file is
+// configured and the physics simulation otherwise, with any configured sensor
+// failure and state detection applied.
+func newSimulator(start time.Time, cfg models.SimulationConfig) (Simulator, error) {
+	switch cfg.GForceMethod {
+	case "", models.GForceAcceleration, models.GForceIMU, models.GForceApprox:
+	default:
+		return nil, fmt.Errorf("unknown gforce method %q", cfg.GForceMethod)
+	}
+	var sim Simulator
+	if cfg.TrajectoryFile != "" {
+		traj, err := LoadTrajectory(cfg.TrajectoryFile)
+		if err != nil {
+			return nil, fmt.Errorf("load trajectory: %w", err)
+		}
+		sim = NewTrajectorySimulation(start, traj, cfg)
+	} else {
+		sim = NewRocketSimulationAt(start, cfg)
+	}
+	if cfg.SensorFailure.Field != "" {
+		var err error
+		if sim, err = newSensorFailure(sim, cfg.SensorFailure); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Detection.Enabled {
+		sim = &stateDetection{sim: sim, detector: stateDetector{cfg: cfg.Detection}}
+	}
+	return sim, nil
+}
+
+// simulationSource ticks a simulation at a fixed interval, or at one per
+// flight phase when a cadence is configured.
+type simulationSource struct {
+	interval time.Duration
+	cfg      models.SimulationConfig
+	sims     *simRegistry
+}
+
+func (s *simulationSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	sim, err := newSimulator(time.Now(), s.cfg)
+	if err != nil {
+		return err
+	}
+	if rs, ok := rocketSimulation(sim); ok && s.sims != nil {
+		s.sims.Add(rs)
+		defer s.sims.Remove(rs)
+	}
+
+	link := newRadioLink(s.cfg.Radio)
+	timer := time.NewTimer(s.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			packet := sim.TickAt(time.Now())
+			timer.Reset(s.cadence(packet.State))
+			if !link.Receive(&packet) {
+				continue
+			}
+			select {
+			case out <- packet:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// cadence returns the interval until the next tick for the flight phase.
+func (s *simulationSource) cadence(state RocketState) time.Duration {
+	seconds := phaseCadence(s.cfg.Cadence, state)
+	if seconds <= 0 {
+		return s.interval
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// phaseCadence is the configured seconds between packets in state's flight
+// phase, 0 when the phase is left at the default.
+func phaseCadence(c models.CadenceConfig, state RocketState) float64 {
+	switch state {
+	case LANDED, CALIBRATION:
+		return c.Pad
+	case IGNITION, LAUNCHING, APEX:
+		return c.Ascent
+	case DESCENDING:
+		return c.Descent
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+func TestSimulationCadence(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	cfg.Cadence = models.CadenceConfig{Pad: 2, Ascent: 0.1}
+	s := &simulationSource{interval: 500 * time.Millisecond, cfg: cfg}
+
+	for state, want := range map[RocketState]time.Duration{
+		LANDED:      2 * time.Second,
+		CALIBRATION: 2 * time.Second,
+		IGNITION:    100 * time.Millisecond,
+		LAUNCHING:   100 * time.Millisecond,
+		APEX:        100 * time.Millisecond,
+		DESCENDING:  500 * time.Millisecond, // not configured
+	} {
+		if got := s.cadence(state); got != want {
+			t.Errorf("state %d: interval %v, want %v", state, got, want)
+		}
+	}
+}
+
+func TestSimulationStepFollowsCadence(t *testing.T) {
+	cfg := models.DefaultPluginSettings().Simulation
+	start := time.UnixMilli(0)
+
+	apogee := func(step time.Duration) float64 {
+		sim := NewRocketSimulationAt(start, cfg)
+		var top float64
+		for now := start; now.Sub(start) < time.Minute; now = now.Add(step) {
+			top = max(top, sim.TickAt(now).Altitude)
+		}
+		return top
+	}
+
+	slow, fast := apogee(500*time.Millisecond), apogee(100*time.Millisecond)
+	if fast < slow*0.95 || fast > slow*1.05 {
+		t.Errorf("apogee %v at 10 Hz vs %v at 2 Hz, want the same flight", fast, slow)
+	}
+}
+
+func TestQuerySeedOverridesSimulation(t *testing.T) {
+	settings := models.DefaultPluginSettings()
+	settings.MaxPacketRate = 0
+	settings.Simulation.Seed = 7
+	d := &Datasource{settings: settings, sims: &simRegistry{}}
+
+	for raw, want := range map[string]int64{
+		`{}`:           7,
+		`{"seed": 42}`: 42,
+		`{"seed": 0}`:  0,
+	} {
+		q, err := parseQuery([]byte(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := d.newSource(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := src.(*simulationSource).cfg.Seed; got != want {
+			t.Errorf("%s: simulation seed %d, want %d", raw, got, want)
+		}
+	}
+}
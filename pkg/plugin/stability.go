@@ -0,0 +1,70 @@
+package plugin
+
+import "math"
+
+const (
+	// unstableTilt is the tilt from vertical, in degrees, averaged over the
+	// window, at which the rocket counts as not flying straight at all.
+	unstableTilt = 30.0
+	// wobbleRate is the RMS pitch and yaw rate, in degrees per second, that
+	// halves the stability index.
+	wobbleRate = 20.0
+)
+
+// stabilityTracker derives stabilityIndex, a coarse proxy for the stability
+// margin in [0, 1], from recent attitude samples: 1 flies straight with damped
+// motion, towards 0 the rocket tilts, wobbles, or oscillates ever harder.
+// Roll is left out, a spin is not an instability.
+//
+// The squared pitch and yaw rates, in (deg/s)², are kept in two halves of the
+// window: values leaving the newer half move into the older one.
+type stabilityTracker struct {
+	tilts *rollingWindow
+	older *rollingWindow
+	newer *rollingWindow
+}
+
+func newStabilityTracker(n int) *stabilityTracker {
+	n = max(n, 2)
+	return &stabilityTracker{
+		tilts: newRollingWindow(n),
+		older: newRollingWindow(n / 2),
+		newer: newRollingWindow(n - n/2),
+	}
+}
+
+// Update adds the packet to the window and returns the index over it.
+func (s *stabilityTracker) Update(prev *TelemetryPacket, packet TelemetryPacket) float64 {
+	var rate float64
+	if prev != nil {
+		pitch := angularRate(*prev, packet, func(p TelemetryPacket) float64 { return p.Pitch })
+		yaw := angularRate(*prev, packet, func(p TelemetryPacket) float64 { return p.Yaw })
+		rate = pitch*pitch + yaw*yaw
+	}
+	s.tilts.Add(TiltFromVertical(packet.Pitch, packet.Roll))
+	if evicted, ok := s.newer.Add(rate); ok {
+		s.older.Add(evicted)
+	}
+
+	all := (s.older.Sum() + s.newer.Sum()) / float64(s.older.Len()+s.newer.Len())
+	var older, newer float64
+	if s.older.Len() > 0 {
+		older, newer = s.older.Mean(), s.newer.Mean()
+	}
+	return StabilityIndex(s.tilts.Mean(), math.Sqrt(all), math.Sqrt(older), math.Sqrt(newer))
+}
+
+// StabilityIndex scores a mean tilt in degrees and the RMS angular rate in
+// deg/s over a window, and over its older and newer halves. The score falls
+// with the tilt and the rate, and further when the rate in the newer half is
+// higher than in the older half, i.e. the oscillation is growing rather than
+// damping out.
+func StabilityIndex(tilt, rate, olderRate, newerRate float64) float64 {
+	straight := 1 - math.Min(tilt/unstableTilt, 1)
+	wobble := 1 / (1 + rate/wobbleRate)
+	growth := 1.0
+	if newerRate > olderRate {
+		growth = (olderRate + wobbleRate) / (newerRate + wobbleRate)
+	}
+	return straight * wobble * growth
+}
@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+)
+
+// oscillate feeds a pitch oscillation around vertical of amplitude(i) degrees
+// at 1 Hz, sampled at 10 Hz, and returns the final stability index.
+func oscillate(amplitude func(i int) float64) float64 {
+	s := newStabilityTracker(20)
+	var prev *TelemetryPacket
+	var index float64
+	for i := range 40 {
+		p := TelemetryPacket{
+			Timestamp: float64(i * 100),
+			Pitch:     90 - math.Abs(amplitude(i)*math.Sin(2*math.Pi*float64(i)/10)),
+		}
+		index = s.Update(prev, p)
+		prev = &p
+	}
+	return index
+}
+
+func TestStabilityIndex(t *testing.T) {
+	if got := oscillate(func(int) float64 { return 0 }); got != 1 {
+		t.Errorf("straight flight: index %v, want 1", got)
+	}
+
+	// A steady 15° tilt scores half.
+	s := newStabilityTracker(5)
+	var got float64
+	for i := range 5 {
+		got = s.Update(&TelemetryPacket{Timestamp: float64(i*100 - 100), Pitch: 75}, TelemetryPacket{Timestamp: float64(i * 100), Pitch: 75})
+	}
+	if math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("steady tilt: index %v, want 0.5", got)
+	}
+
+	damped := oscillate(func(i int) float64 { return 8 * math.Exp(-float64(i)/10) })
+	steady := oscillate(func(int) float64 { return 3 })
+	growing := oscillate(func(i int) float64 { return 0.5 * math.Exp(float64(i)/10) })
+	if !(damped > steady && steady > growing) {
+		t.Errorf("want damped > steady > growing, got %.3f, %.3f, %.3f", damped, steady, growing)
+	}
+	if damped < 0.8 {
+		t.Errorf("damped oscillation: index %.3f, want close to 1", damped)
+	}
+	if growing > 0.3 {
+		t.Errorf("growing oscillation: index %.3f, want close to 0", growing)
+	}
+}
+
+func TestStabilityIndexRange(t *testing.T) {
+	for _, tc := range [][4]float64{
+		{0, 0, 0, 0},
+		{180, 1e4, 1e3, 1e4},
+		{0, 0, 1e4, 0},
+	} {
+		if got := StabilityIndex(tc[0], tc[1], tc[2], tc[3]); got < 0 || got > 1 {
+			t.Errorf("StabilityIndex(%v) = %v, want it in [0, 1]", tc, got)
+		}
+	}
+}
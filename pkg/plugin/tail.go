@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+)
+
+// fileSource reads packets from a capture file. With follow set it keeps
+// reading appended lines like tail -f, reopening the file when it is rotated
+// or truncated. With reverse set it replays the file backwards instead.
+type fileSource struct {
+	path         string
+	follow       bool
+	reverse      bool
+	parser       *PacketParser
+	errs         *parseErrorLog
+	pollInterval time.Duration
+}
+
+func (s *fileSource) Run(ctx context.Context, out chan<- TelemetryPacket) error {
+	if s.reverse {
+		return s.runReverse(ctx, out)
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	var partial string
+
+	for {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+
+		if err == nil {
+			s.emit(ctx, out, partial+line)
+			partial = ""
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		// Keep an unterminated line around until the writer finishes it.
+		partial += line
+
+		if !s.follow {
+			if partial != "" {
+				s.emit(ctx, out, partial)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+
+		reopen, err := s.rotated(f, offset)
+		if err != nil {
+			// The file may be briefly missing mid-rotation.
+			log.DefaultLogger.Debug("Capture file unavailable", "path", s.path, "error", err)
+			continue
+		}
+		if reopen {
+			log.DefaultLogger.Info("Capture file rotated, reopening", "path", s.path)
+			nf, err := os.Open(s.path)
+			if err != nil {
+				continue
+			}
+			f.Close()
+			f = nf
+			reader.Reset(f)
+			offset = 0
+			partial = ""
+		}
+	}
+}
+
+// runReverse emits the file's packets from last to first. Timestamps are
+// mirrored around the middle of the recording, so the time axis still runs
+// forward from the first timestamp with the original spacing.
+func (s *fileSource) runReverse(ctx context.Context, out chan<- TelemetryPacket) error {
+	packets, err := readPackets(s.path, s.parser, nil)
+	if err != nil {
+		return err
+	}
+	if len(packets) == 0 {
+		return nil
+	}
+	first, last := packets[0].Timestamp, packets[len(packets)-1].Timestamp
+	for i := len(packets) - 1; i >= 0; i-- {
+		p := packets[i]
+		p.Timestamp = first + last - p.Timestamp
+		select {
+		case out <- p:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// rotated reports whether the file at path is no longer the one being read,
+// or has been truncated below the current read offset.
+func (s *fileSource) rotated(f *os.File, offset int64) (bool, error) {
+	current, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+	open, err := f.Stat()
+	if err != nil {
+		return true, nil
+	}
+	return !os.SameFile(current, open) || current.Size() < offset, nil
+}
+
+func (s *fileSource) emit(ctx context.Context, out chan<- TelemetryPacket, line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	packets, errs := s.parser.ParsePackets(line)
+	for _, err := range errs {
+		log.DefaultLogger.Debug("Skipping unparseable record", "line", line, "error", err)
+		s.errs.Record(err)
+	}
+	for _, packet := range packets {
+		select {
+		case out <- packet:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func radioLine(altitude string) string {
+	return "1000,90,0,0,1.0," + altitude + ",37.7749,-122.4194,LAUNCHING,10\n"
+}
+
+func receive(t *testing.T, packets <-chan TelemetryPacket) TelemetryPacket {
+	t.Helper()
+	select {
+	case p := <-packets:
+		return p
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for packet")
+	}
+	return TelemetryPacket{}
+}
+
+func TestFileSourceFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.log")
+	if err := os.WriteFile(path, []byte(radioLine("10")+"garbage\n"+radioLine("20")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	packets := make(chan TelemetryPacket)
+	src := &fileSource{path: path, follow: true, parser: defaultParser, pollInterval: 10 * time.Millisecond}
+	go src.Run(ctx, packets)
+
+	if p := receive(t, packets); p.Altitude != 10 {
+		t.Fatalf("got altitude %v, want 10", p.Altitude)
+	}
+	if p := receive(t, packets); p.Altitude != 20 {
+		t.Fatalf("got altitude %v, want 20", p.Altitude)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(radioLine("30"))
+	f.Close()
+
+	if p := receive(t, packets); p.Altitude != 30 {
+		t.Fatalf("got altitude %v, want 30", p.Altitude)
+	}
+
+	// Truncate and start over, as a capture tool does on rotation.
+	if err := os.WriteFile(path, []byte(radioLine("5")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if p := receive(t, packets); p.Altitude != 5 {
+		t.Fatalf("got altitude %v after truncation, want 5", p.Altitude)
+	}
+}
+
+func TestFileSourceNoFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.log")
+	if err := os.WriteFile(path, []byte(`{"altitude": 42}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	packets := make(chan TelemetryPacket, 1)
+	src := &fileSource{path: path, parser: defaultParser}
+	if err := src.Run(context.Background(), packets); err != nil {
+		t.Fatal(err)
+	}
+	if p := <-packets; p.Altitude != 42 {
+		t.Fatalf("got altitude %v, want 42", p.Altitude)
+	}
+}
+
+func TestFileSourceReverse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.log")
+	lines := "1000,90,0,0,1,0,37.7,-122.4,LAUNCHING,10\n" +
+		"1500,90,0,0,1,50,37.7,-122.4,LAUNCHING,10\n" +
+		"3000,90,0,0,1,120,37.7,-122.4,APEX,10\n"
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	packets := make(chan TelemetryPacket, 3)
+	src := &fileSource{path: path, reverse: true, parser: defaultParser}
+	if err := src.Run(context.Background(), packets); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct{ timestamp, altitude float64 }{{1000, 120}, {2500, 50}, {3000, 0}} {
+		if p := <-packets; p.Timestamp != want.timestamp || p.Altitude != want.altitude {
+			t.Errorf("got altitude %v at %v, want %v at %v", p.Altitude, p.Timestamp, want.altitude, want.timestamp)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package plugin
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+// Waypoint is one sample of a planned or measured trajectory.
+type Waypoint struct {
+	Time      float64 // s since ignition
+	Altitude  float64 // m
+	Latitude  float64
+	Longitude float64
+	Pitch     float64
+	Roll      float64
+	Yaw       float64
+}
+
+// Trajectory is a time ordered list of waypoints.
+type Trajectory []Waypoint
+
+// LoadTrajectory reads a trajectory CSV file, see ParseTrajectory.
+func LoadTrajectory(path string) (Trajectory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTrajectory(f)
+}
+
+// ParseTrajectory reads waypoints as CSV rows of
+//
+//	time,altitude,lat,lon,pitch,roll,yaw
+//
+// with time in seconds since ignition and altitude in meters. Lines starting
+// with # and a header row whose first column is not a number are skipped, so
+// OpenRocket exports only need their columns arranged in this order.
+func ParseTrajectory(r io.Reader) (Trajectory, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 7
+	reader.TrimLeadingSpace = true
+
+	var traj Trajectory
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]float64, len(record))
+		for i, s := range record {
+			values[i], err = strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			if len(traj) == 0 && line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("trajectory row %d: %w", line, err)
+		}
+
+		traj = append(traj, Waypoint{
+			Time:      values[0],
+			Altitude:  values[1],
+			Latitude:  values[2],
+			Longitude: values[3],
+			Pitch:     values[4],
+			Roll:      values[5],
+			Yaw:       values[6],
+		})
+	}
+
+	if len(traj) < 2 {
+		return nil, fmt.Errorf("trajectory needs at least 2 waypoints, got %d", len(traj))
+	}
+	sort.SliceStable(traj, func(i, j int) bool { return traj[i].Time < traj[j].Time })
+	return traj, nil
+}
+
+// Duration is the time from the first to the last waypoint.
+func (t Trajectory) Duration() float64 {
+	return t[len(t)-1].Time - t[0].Time
+}
+
+// At linearly interpolates the trajectory at tm seconds, clamping to the
+// first and last waypoints.
+func (t Trajectory) At(tm float64) Waypoint {
+	if tm <= t[0].Time {
+		return t[0]
+	}
+	last := t[len(t)-1]
+	if tm >= last.Time {
+		return last
+	}
+
+	i := sort.Search(len(t), func(i int) bool { return t[i].Time >= tm })
+	a, b := t[i-1], t[i]
+	f := (tm - a.Time) / (b.Time - a.Time)
+	lerp := func(x, y float64) float64 { return x + (y-x)*f }
+
+	return Waypoint{
+		Time:      tm,
+		Altitude:  lerp(a.Altitude, b.Altitude),
+		Latitude:  lerp(a.Latitude, b.Latitude),
+		Longitude: lerp(a.Longitude, b.Longitude),
+		Pitch:     lerp(a.Pitch, b.Pitch),
+		Roll:      wrapDegrees(a.Roll + AngularDelta(a.Roll, b.Roll)*f),
+		Yaw:       wrapDegrees(a.Yaw + AngularDelta(a.Yaw, b.Yaw)*f),
+	}
+}
+
+// apogeeTime returns the time of the highest waypoint.
+func (t Trajectory) apogeeTime() float64 {
+	best := t[0]
+	for _, w := range t {
+		if w.Altitude > best.Altitude {
+			best = w
+		}
+	}
+	return best.Time
+}
+
+// TrajectorySimulation replays a trajectory on a loop, sitting on the pad for
+// the configured launch delay before each flight.
+type TrajectorySimulation struct {
+	traj      Trajectory
+	cfg       models.SimulationConfig
+	startTime time.Time
+	apogee    float64
+	state     RocketState
+}
+
+func NewTrajectorySimulation(start time.Time, traj Trajectory, cfg models.SimulationConfig) *TrajectorySimulation {
+	return &TrajectorySimulation{traj: traj, cfg: cfg, startTime: start, apogee: traj.apogeeTime(), state: LANDED}
+}
+
+// TickAt samples the trajectory at now.
+func (s *TrajectorySimulation) TickAt(now time.Time) TelemetryPacket {
+	cycle := s.cfg.LaunchDelay + s.traj.Duration()
+	elapsed := now.Sub(s.startTime).Seconds()
+	if cycle > 0 && elapsed > cycle {
+		elapsed = elapsed - cycle*float64(int(elapsed/cycle))
+	}
+	tm := s.traj[0].Time + elapsed - s.cfg.LaunchDelay
+
+	prev := s.state
+	switch {
+	case elapsed < s.cfg.LaunchDelay || tm >= s.traj[len(s.traj)-1].Time:
+		s.state = LANDED
+	case tm < s.apogee:
+		s.state = LAUNCHING
+	case prev == LAUNCHING:
+		s.state = APEX
+	default:
+		s.state = DESCENDING
+	}
+
+	w := s.traj.At(tm)
+
+	// Vertical speed and acceleration by central differences of the
+	// interpolated path.
+	const h = 0.25
+	speed := (s.traj.At(tm+h).Altitude - s.traj.At(tm-h).Altitude) / (2 * h)
+	accel := (s.traj.At(tm+h).Altitude - 2*w.Altitude + s.traj.At(tm-h).Altitude) / (h * h)
+
+	return TelemetryPacket{
+		Signal:    -50,
+		Timestamp: float64(now.UnixMilli()),
+		Pitch:     w.Pitch,
+		Roll:      w.Roll,
+		Yaw:       w.Yaw,
+		GForce:    GForce(s.cfg.GForceMethod, speed, 0, accel),
+		Altitude:  w.Altitude,
+		GPS: GPS{
+			Latitude:  w.Latitude,
+			Longitude: w.Longitude,
+		},
+		State:          s.state,
+		LoopsPerSecond: 10,
+	}
+}
@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dibes/rocket-telemtry/pkg/models"
+)
+
+const testTrajectory = `# planned flight
+time,altitude,lat,lon,pitch,roll,yaw
+0,0,37.0,-122.0,90,350,0
+10,1000,37.001,-122.001,80,10,0
+30,0,37.002,-122.002,0,20,0
+`
+
+func TestParseTrajectory(t *testing.T) {
+	traj, err := ParseTrajectory(strings.NewReader(testTrajectory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traj) != 3 || traj.Duration() != 30 {
+		t.Fatalf("got %d waypoints over %vs, want 3 over 30s", len(traj), traj.Duration())
+	}
+
+	w := traj.At(5)
+	if w.Altitude != 500 || w.Pitch != 85 || w.Roll != 0 {
+		t.Errorf("At(5) = %+v, want altitude 500, pitch 85, roll 0", w)
+	}
+	if w := traj.At(-1); w.Altitude != 0 {
+		t.Errorf("At(-1) altitude = %v, want clamped 0", w.Altitude)
+	}
+
+	if _, err := ParseTrajectory(strings.NewReader("0,0,0,0,0,0,0\n1,x,0,0,0,0,0\n")); err == nil {
+		t.Error("expected error for non-numeric row")
+	}
+}
+
+func TestTrajectorySimulationStates(t *testing.T) {
+	traj, err := ParseTrajectory(strings.NewReader(testTrajectory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := models.DefaultPluginSettings().Simulation
+
+	start := time.UnixMilli(0)
+	sim := NewTrajectorySimulation(start, traj, cfg)
+
+	var seq []RocketState
+	for now := start; now.Sub(start) < 45*time.Second; now = now.Add(500 * time.Millisecond) {
+		state := sim.TickAt(now).State
+		if len(seq) == 0 || seq[len(seq)-1] != state {
+			seq = append(seq, state)
+		}
+	}
+
+	want := []RocketState{LANDED, LAUNCHING, APEX, DESCENDING, LANDED, LAUNCHING}
+	if len(seq) != len(want) {
+		t.Fatalf("got state sequence %v, want %v", seq, want)
+	}
+	for i := range want {
+		if seq[i] != want[i] {
+			t.Fatalf("got state sequence %v, want %v", seq, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package plugin
+
+// Unit is a display unit a field can be converted to. Values are stored in SI
+// and multiplied by Scale on the way out.
+type Unit struct {
+	ID          string  `json:"id"`
+	Label       string  `json:"label"`
+	GrafanaUnit string  `json:"grafanaUnit"`
+	Scale       float64 `json:"-"`
+}
+
+var lengthUnits = []Unit{
+	{ID: "m", Label: "meters", GrafanaUnit: "lengthm", Scale: 1},
+	{ID: "ft", Label: "feet", GrafanaUnit: "lengthft", Scale: 3.28084},
+	{ID: "km", Label: "kilometers", GrafanaUnit: "lengthkm", Scale: 0.001},
+	{ID: "mi", Label: "miles", GrafanaUnit: "lengthmi", Scale: 1 / 1609.344},
+}
+
+// altitudeUnits adds flight levels, hundreds of feet, to the length units.
+// Grafana shows them with an FL prefix, e.g. FL350.
+var altitudeUnits = append(lengthUnits[:len(lengthUnits):len(lengthUnits)],
+	Unit{ID: "FL", Label: "flight level", GrafanaUnit: "prefix:FL", Scale: 3.28084 / 100},
+)
+
+// descentRateUnits default to feet per minute, the US recovery convention; a
+// safe main chute descent is around 900 to 1200 ft/min.
+var descentRateUnits = []Unit{
+	{ID: "ft/min", Label: "feet per minute", GrafanaUnit: "suffix: ft/min", Scale: 3.28084 * 60},
+	{ID: "ft/s", Label: "feet per second", GrafanaUnit: "suffix: ft/s", Scale: 3.28084},
+	{ID: "m/s", Label: "meters per second", GrafanaUnit: "velocityms", Scale: 1},
+}
+
+var velocityUnits = []Unit{
+	{ID: "m/s", Label: "meters per second", GrafanaUnit: "velocityms", Scale: 1},
+	{ID: "ft/s", Label: "feet per second", GrafanaUnit: "suffix: ft/s", Scale: 3.28084},
+	{ID: "km/h", Label: "kilometers per hour", GrafanaUnit: "velocitykmh", Scale: 3.6},
+	{ID: "mph", Label: "miles per hour", GrafanaUnit: "velocitymph", Scale: 3600 / 1609.344},
+}
+
+// varianceUnits are the squares of length units.
+var varianceUnits = []Unit{
+	{ID: "m²", Label: "square meters", GrafanaUnit: "suffix: m²", Scale: 1},
+	{ID: "ft²", Label: "square feet", GrafanaUnit: "suffix: ft²", Scale: 3.28084 * 3.28084},
+}
+
+var (
+	degreeUnits     = []Unit{{ID: "deg", Label: "degrees", GrafanaUnit: "degree", Scale: 1}}
+	degreeRateUnits = []Unit{{ID: "deg/s", Label: "degrees per second", GrafanaUnit: "deg/s", Scale: 1}}
+	dbmUnits        = []Unit{{ID: "dBm", Label: "dBm", GrafanaUnit: "dBm", Scale: 1}}
+	percentUnits    = []Unit{{ID: "%", Label: "percent", GrafanaUnit: "percent", Scale: 1}}
+	ratioUnits      = []Unit{{ID: "ratio", Label: "0 to 1", GrafanaUnit: "percentunit", Scale: 1}}
+	secondUnits     = []Unit{{ID: "s", Label: "seconds", GrafanaUnit: "s", Scale: 1}}
+)
+
+// findUnit returns the unit with the given ID, or the first (default) unit
+// when id is empty or unknown.
+func findUnit(units []Unit, id string) Unit {
+	for _, u := range units {
+		if u.ID == id {
+			return u
+		}
+	}
+	return units[0]
+}
@@ -0,0 +1,24 @@
+import { channelKey } from './channel';
+
+describe('channelKey', () => {
+  it('opens a stream per rocket and seed', () => {
+    const base = { refId: 'A', fields: ['altitude'] };
+    expect(channelKey({ ...base, rocket: 0 })).not.toEqual(channelKey({ ...base, rocket: 1 }));
+    expect(channelKey({ ...base, seed: 1 })).not.toEqual(channelKey({ ...base, seed: 2 }));
+  });
+
+  it('covers nested options', () => {
+    expect(channelKey({ refId: 'A', filter: { alpha: 0.3 } })).not.toEqual(
+      channelKey({ refId: 'A', filter: { alpha: 0.5 } })
+    );
+    expect(channelKey({ refId: 'A', deadband: { pitch: 1 } })).not.toEqual(
+      channelKey({ refId: 'A', deadband: { roll: 1 } })
+    );
+  });
+
+  it('shares the stream between panels with the same options', () => {
+    expect(channelKey({ refId: 'A', rocket: 1, units: { altitude: 'ft', pitch: 'rad' } })).toEqual(
+      channelKey({ refId: 'B', units: { pitch: 'rad', altitude: 'ft' }, rocket: 1 })
+    );
+  });
+});
@@ -0,0 +1,35 @@
+import { MyQuery } from './types';
+
+// Query properties Grafana sets that do not change what the backend streams.
+const nonStreamKeys = new Set(['refId', 'datasource', 'hide', 'key']);
+
+// stableStringify serializes a value as JSON with object keys sorted, so equal
+// queries serialize the same regardless of the order their options were set.
+function stableStringify(value: unknown): string {
+  if (Array.isArray(value)) {
+    return `[${value.map(stableStringify).join(',')}]`;
+  }
+  if (value !== null && typeof value === 'object') {
+    const entries = Object.entries(value as Record<string, unknown>)
+      .filter(([, v]) => v !== undefined)
+      .sort(([a], [b]) => (a < b ? -1 : a > b ? 1 : 0));
+    return `{${entries.map(([k, v]) => `${JSON.stringify(k)}:${stableStringify(v)}`).join(',')}}`;
+  }
+  return JSON.stringify(value) ?? 'null';
+}
+
+// channelKey encodes the stream options of a query into a live channel path
+// segment. Grafana Live runs one stream per channel path, so the key is a hash
+// of the whole query: changing any option, such as the rocket or seed, opens a
+// new stream rather than joining another panel's.
+export function channelKey(query: MyQuery): string {
+  const options = Object.fromEntries(Object.entries(query).filter(([k]) => !nonStreamKeys.has(k)));
+  const text = stableStringify(options);
+  // 32-bit FNV-1a.
+  let hash = 0x811c9dc5;
+  for (let i = 0; i < text.length; i++) {
+    hash ^= text.charCodeAt(i);
+    hash = Math.imul(hash, 0x01000193);
+  }
+  return (hash >>> 0).toString(36);
+}
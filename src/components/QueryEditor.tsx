@@ -1,36 +1,44 @@
-import React from 'react';
-import { InlineField, MultiCombobox } from '@grafana/ui';
-import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import React, { useEffect, useState } from 'react';
+import { Combobox, InlineField, MultiCombobox } from '@grafana/ui';
+import { QueryEditorProps } from '@grafana/data';
 import { DataSource } from '../datasource';
-import { MyDataSourceOptions, MyQuery } from '../types';
+import { FieldInfo, MyDataSourceOptions, MyQuery } from '../types';
 
 type Props = QueryEditorProps<DataSource, MyQuery, MyDataSourceOptions>;
 
-const fieldOptions: Array<SelectableValue<string>> = [
-  { label: 'Altitude', value: 'altitude' },
-  { label: 'Latitude', value: 'latitude' },
-  { label: 'Longitude', value: 'longitude' },
-  { label: 'State', value: 'state' },
-  { label: 'Pitch', value: 'pitch' },
-  { label: 'Roll', value: 'roll' },
-  { label: 'Yaw', value: 'yaw' },
-  { label: 'G-Force', value: 'gforce' },
-  { label: 'Signal', value: 'signal' },
-];
+export function QueryEditor({ datasource, query, onChange, onRunQuery }: Props) {
+  const { fields, units } = query;
+  const [available, setAvailable] = useState<FieldInfo[]>([]);
 
-export function QueryEditor({ query, onChange, onRunQuery }: Props) {
-  const { fields } = query;
+  useEffect(() => {
+    datasource.getResource<FieldInfo[]>('fields').then(setAvailable);
+  }, [datasource]);
+
+  const labelFor = (name: string) => available.find((f) => f.name === name)?.label ?? name;
+
+  // Offer a unit choice for every selected field that has more than one unit.
+  const convertible = available.filter((f) => (f.units?.length ?? 0) > 1 && (!fields?.length || fields.includes(f.name)));
 
   return (
     <>
       <InlineField label="Fields" labelWidth={16} tooltip="Select fields to stream">
         <MultiCombobox
-          options={fieldOptions.map((field) => ({ label: field.label, value: field.value as string }))}
-          value={fields?.map((field) => ({ label: field, value: field as string }))}
+          options={available.map((field) => ({ label: field.label, value: field.name }))}
+          value={fields?.map((field) => ({ label: labelFor(field), value: field }))}
           onChange={(value) => onChange({ ...query, fields: value.map((v) => v.value as string) })}
           placeholder="Select fields (default: all)"
         />
       </InlineField>
+      {convertible.map((field) => (
+        <InlineField key={field.name} label={`${field.label} unit`} labelWidth={16}>
+          <Combobox
+            options={field.units!.map((unit) => ({ label: unit.label, value: unit.id }))}
+            value={units?.[field.name] ?? field.units![0].id}
+            onChange={(option) => onChange({ ...query, units: { ...units, [field.name]: option.value } })}
+            width={24}
+          />
+        </InlineField>
+      ))}
     </>
   );
 }
@@ -9,6 +9,7 @@ import {
 import { DataSourceWithBackend, getGrafanaLiveSrv, getTemplateSrv } from '@grafana/runtime';
 
 import { MyQuery, MyDataSourceOptions, DEFAULT_QUERY } from './types';
+import { channelKey } from './channel';
 import { merge, Observable } from 'rxjs';
 
 export class DataSource extends DataSourceWithBackend<MyQuery, MyDataSourceOptions> {
@@ -33,7 +34,7 @@ export class DataSource extends DataSourceWithBackend<MyQuery, MyDataSourceOptio
         addr: {
           scope: LiveChannelScope.DataSource,
           namespace: this.uid,
-          path: `my-ws/custom-${query.fields?.join('_')}`, // this will allow each new query to create a new connection
+          path: `my-ws/custom-${channelKey(query)}`, // this will allow each new query to create a new connection
           data: {
             ...query,
           },
@@ -3,6 +3,53 @@ import { DataQuery } from '@grafana/schema';
 
 export interface MyQuery extends DataQuery {
   fields?: string[];
+  maxPoints?: number;
+  attitudePrecision?: number;
+  units?: Record<string, string>;
+  omitUnchanged?: boolean;
+  keyframeInterval?: number;
+  filter?: AltitudeFilter;
+  deadband?: Record<string, number>;
+  minAltitude?: number;
+  summary?: boolean;
+  smoothState?: boolean;
+  stateResetAfter?: number;
+  signalPerReceiver?: boolean;
+  lossWindow?: number;
+  gforceWindow?: number;
+  stabilityWindow?: number;
+  apogeeSmoothing?: number;
+  phaseBy?: 'state' | 'velocity';
+  seed?: number;
+  rocket?: number;
+  launchAzimuth?: number;
+  warmupDiscard?: { seconds?: number; samples?: number };
+}
+
+/**
+ * Filter behind the filteredAltitude field
+ */
+export interface AltitudeFilter {
+  type?: 'ema' | 'kalman';
+  alpha?: number;
+  processNoise?: number;
+  measurementNoise?: number;
+}
+
+/**
+ * A field advertised by the backend's /fields resource
+ */
+export interface FieldInfo {
+  name: string;
+  label: string;
+  type: 'number' | 'boolean' | 'string';
+  units?: FieldUnit[];
+}
+
+export interface FieldUnit {
+  id: string;
+  label: string;
+  grafanaUnit: string;
 }
 
 export const DEFAULT_QUERY: Partial<MyQuery> = {